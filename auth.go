@@ -0,0 +1,172 @@
+package jin
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// AuthUserKey 是 BasicAuth 中保存已认证用户名的键
+const AuthUserKey = "user"
+
+// Accounts 定义了授权登录的 用户名/密码 列表
+type Accounts map[string]string
+
+// BasicAuthVerifier 校验用户名和密码，返回是否通过
+type BasicAuthVerifier func(user, password string) bool
+
+type authPair struct {
+	value string
+	user  string
+}
+
+type authPairs []authPair
+
+func (a authPairs) searchCredential(authValue string) (string, bool) {
+	if authValue == "" {
+		return "", false
+	}
+	found, user := 0, ""
+	// 遍历所有账号，不提前返回，让比较时间与匹配位置无关
+	for _, pair := range a {
+		if subtle.ConstantTimeCompare([]byte(pair.value), []byte(authValue)) == 1 {
+			found = 1
+			user = pair.user
+		}
+	}
+	return user, found == 1
+}
+
+// BasicAuthForRealm 返回一个 Basic HTTP 认证中间件，它接收 map[string]string 作为参数，
+// 键为用户名，值为密码，以及 Realm 的名字
+// 如果 realm 为空，默认使用 "Authorization Required"
+// (参见 http://tools.ietf.org/html/rfc2617#section-1.2)
+func BasicAuthForRealm(accounts Accounts, realm string) HandlerFunc {
+	pairs := processAccounts(accounts)
+	return basicAuth(realm, func(c *Context) (string, bool) {
+		return pairs.searchCredential(c.requestHeader("Authorization"))
+	})
+}
+
+// BasicAuth 返回一个 Basic HTTP 认证中间件，它接收 map[string]string 作为参数，
+// 键为用户名，值为密码
+func BasicAuth(accounts Accounts) HandlerFunc {
+	return BasicAuthForRealm(accounts, "")
+}
+
+// BasicAuthWithVerifier 返回一个使用 verify 校验凭证的 Basic HTTP 认证中间件，
+// 账号可以来自 htpasswd 文件等外部来源，verify 需要自行保证比较是常量时间的
+func BasicAuthWithVerifier(verify BasicAuthVerifier, realm string) HandlerFunc {
+	return basicAuth(realm, func(c *Context) (string, bool) {
+		user, password, ok := c.Request.BasicAuth()
+		if !ok || !verify(user, password) {
+			return "", false
+		}
+		return user, true
+	})
+}
+
+func basicAuth(realm string, check func(c *Context) (string, bool)) HandlerFunc {
+	if realm == "" {
+		realm = "Authorization Required"
+	}
+	realm = "Basic realm=" + strconv.Quote(realm)
+	return func(c *Context) {
+		user, found := check(c)
+		if !found {
+			// 凭证不匹配，返回 401 并中止处理器链
+			c.Writer.Header().Set("WWW-Authenticate", realm)
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+
+		// 凭证匹配，把用户名保存在 AuthUserKey 中，之后可以通过 c.MustGet(jin.AuthUserKey) 读取
+		c.Set(AuthUserKey, user)
+	}
+}
+
+func processAccounts(accounts Accounts) authPairs {
+	assert1(len(accounts) > 0, "Empty list of authorized credentials")
+	pairs := make(authPairs, 0, len(accounts))
+	for user, password := range accounts {
+		assert1(user != "", "User can not be empty")
+		value := authorizationHeader(user, password)
+		pairs = append(pairs, authPair{
+			value: value,
+			user:  user,
+		})
+	}
+	return pairs
+}
+
+func authorizationHeader(user, password string) string {
+	base := user + ":" + password
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(base))
+}
+
+// HtpasswdVerifier 读取 htpasswd 文件并返回对应的 BasicAuthVerifier
+// 支持 bcrypt ($2y$) 和 SHA1 ({SHA}) 两种格式
+func HtpasswdVerifier(file string) (BasicAuthVerifier, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	hashes := make(map[string]string)
+	cost := bcrypt.DefaultCost
+	scanner := bufio.NewScanner(f)
+	for lineno := 1; scanner.Scan(); lineno++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			// 不把行内容放进错误，避免泄露密码哈希
+			return nil, errors.New("htpasswd: malformed line " + strconv.Itoa(lineno))
+		}
+		hashes[parts[0]] = parts[1]
+		if c, err := bcrypt.Cost([]byte(parts[1])); err == nil && c > cost {
+			cost = c
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	// 未知用户也做一次同样代价的 bcrypt 比较，让响应时间不暴露用户是否存在
+	dummy, err := bcrypt.GenerateFromPassword([]byte("jin-htpasswd-dummy"), cost)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(user, password string) bool {
+		hash, ok := hashes[user]
+		if !ok {
+			bcrypt.CompareHashAndPassword(dummy, []byte(password))
+			return false
+		}
+		return checkHtpasswd(hash, password)
+	}, nil
+}
+
+func checkHtpasswd(hash, password string) bool {
+	switch {
+	case strings.HasPrefix(hash, "$2y$"), strings.HasPrefix(hash, "$2a$"), strings.HasPrefix(hash, "$2b$"):
+		return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+	case strings.HasPrefix(hash, "{SHA}"):
+		sum := sha1.Sum([]byte(password))
+		expected := "{SHA}" + base64.StdEncoding.EncodeToString(sum[:])
+		return subtle.ConstantTimeCompare([]byte(hash), []byte(expected)) == 1
+	}
+	return false
+}
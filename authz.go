@@ -0,0 +1,153 @@
+package jin
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// PrincipalKey 是认证中间件在 Context.Keys 中保存当前主体的键
+const PrincipalKey = "jin/principal"
+
+var (
+	// ErrUnauthenticated 表示请求没有经过认证
+	ErrUnauthenticated = errors.New("jin: authentication required")
+	// ErrForbidden 表示主体没有访问权限
+	ErrForbidden = errors.New("jin: permission denied")
+)
+
+// Principal 是经过认证的主体，认证中间件把它保存在 Context.Keys[PrincipalKey]
+type Principal interface {
+	// Name 返回主体的标识，用于审计日志
+	Name() string
+	// HasRole 判断主体是否拥有角色
+	HasRole(role string) bool
+	// HasPermission 判断主体是否拥有权限
+	HasPermission(permission string) bool
+}
+
+// Policy 是自定义的授权规则，返回 true 表示允许访问
+type Policy func(c *Context, p Principal) bool
+
+// userPrincipal 是只有用户名的主体，用于 BasicAuth 认证的请求
+type userPrincipal string
+
+func (u userPrincipal) Name() string              { return string(u) }
+func (u userPrincipal) HasRole(string) bool       { return false }
+func (u userPrincipal) HasPermission(string) bool { return false }
+
+// Principal 返回当前请求经过认证的主体
+// 没有设置 PrincipalKey 但经过 BasicAuth 认证时，返回一个只有用户名、没有角色和权限的主体
+func (c *Context) Principal() (Principal, bool) {
+	if p, ok := c.Keys[PrincipalKey].(Principal); ok {
+		return p, true
+	}
+	if user, ok := c.Keys[AuthUserKey].(string); ok {
+		return userPrincipal(user), true
+	}
+	return nil, false
+}
+
+// RequireRoles 返回一个要求主体拥有 roles 中任意一个角色的中间件
+// 通过 group.Use 使用时不会出现在 Engine.Routes() 中，需要报告时使用 RouterGroup.RequireRoles
+func RequireRoles(roles ...string) HandlerFunc {
+	requirement, policy := roleRequirement(roles)
+	return authorize(requirement, policy)
+}
+
+// RequirePermissions 返回一个要求主体拥有 permissions 中所有权限的中间件
+// 通过 group.Use 使用时不会出现在 Engine.Routes() 中，需要报告时使用 RouterGroup.RequirePermissions
+func RequirePermissions(permissions ...string) HandlerFunc {
+	requirement, policy := permissionRequirement(permissions)
+	return authorize(requirement, policy)
+}
+
+// Authorize 返回一个使用自定义规则授权的中间件，name 会出现在审计日志中
+// 通过 group.Use 使用时不会出现在 Engine.Routes() 中，需要报告时使用 RouterGroup.Authorize
+func Authorize(name string, policy Policy) HandlerFunc {
+	assert1(policy != nil, "policy can not be nil")
+	return authorize("policy:"+name, policy)
+}
+
+func roleRequirement(roles []string) (string, Policy) {
+	assert1(len(roles) > 0, "at least one role is required")
+	return "role:" + strings.Join(roles, "|"), func(c *Context, p Principal) bool {
+		for _, role := range roles {
+			if p.HasRole(role) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func permissionRequirement(permissions []string) (string, Policy) {
+	assert1(len(permissions) > 0, "at least one permission is required")
+	return "permission:" + strings.Join(permissions, "&"), func(c *Context, p Principal) bool {
+		for _, permission := range permissions {
+			if !p.HasPermission(permission) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// authorize 是所有授权中间件共用的实现
+// 没有主体时以 401 中止，规则不通过时以 403 中止，两者都作为 ErrorTypePublic 错误记录，
+// 拒绝访问会以 Warn 级别写入审计日志，允许访问以 Debug 级别写入
+func authorize(requirement string, policy Policy) HandlerFunc {
+	return func(c *Context) {
+		p, ok := c.Principal()
+		if !ok {
+			c.auditAuthorization(slog.LevelWarn, "authorization denied", "", requirement)
+			c.AbortWithError(http.StatusUnauthorized, ErrUnauthenticated).SetType(ErrorTypePublic)
+			return
+		}
+		if !policy(c, p) {
+			c.auditAuthorization(slog.LevelWarn, "authorization denied", p.Name(), requirement)
+			c.AbortWithError(http.StatusForbidden, ErrForbidden).SetType(ErrorTypePublic)
+			return
+		}
+		c.auditAuthorization(slog.LevelDebug, "authorization granted", p.Name(), requirement)
+	}
+}
+
+func (c *Context) auditAuthorization(level slog.Level, msg, principal, requirement string) {
+	c.Logger().LogAttrs(c.Request.Context(), level, msg,
+		slog.String("principal", principal),
+		slog.String("requirement", requirement),
+		slog.String("method", c.Request.Method),
+		slog.String("path", c.Request.URL.Path),
+	)
+}
+
+// RequireRoles 在组上使用 jin.RequireRoles 中间件，并把要求记录在之后注册的路由上，
+// Engine.Routes() 会报告这些路由的 Permissions
+func (group *RouterGroup) RequireRoles(roles ...string) IRoutes {
+	requirement, policy := roleRequirement(roles)
+	return group.UseAuthorization(authorize(requirement, policy), requirement)
+}
+
+// RequirePermissions 在组上使用 jin.RequirePermissions 中间件，并把要求记录在之后注册的路由上
+func (group *RouterGroup) RequirePermissions(permissions ...string) IRoutes {
+	requirement, policy := permissionRequirement(permissions)
+	return group.UseAuthorization(authorize(requirement, policy), requirement)
+}
+
+// Authorize 在组上使用 jin.Authorize 中间件，并把 "policy:name" 记录在之后注册的路由上
+func (group *RouterGroup) Authorize(name string, policy Policy) IRoutes {
+	return group.UseAuthorization(Authorize(name, policy), "policy:"+name)
+}
+
+// UseAuthorization 在组上使用自定义的授权中间件，并把 requirements 记录在之后注册的路由上
+// 与 Use 一样只影响之后注册的路由和子组
+func (group *RouterGroup) UseAuthorization(handler HandlerFunc, requirements ...string) IRoutes {
+	assert1(handler != nil, "handler can not be nil")
+	group.requirements = append(group.requirements[:len(group.requirements):len(group.requirements)], requirements...)
+	if group.root {
+		return group.engine.Use(handler)
+	}
+	return group.Use(handler)
+}
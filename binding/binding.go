@@ -1,12 +1,20 @@
 package binding
 
-import "net/http"
+import (
+	"errors"
+	"net/http"
+
+	"gopkg.in/go-playground/validator.v9"
+)
 
 const (
-	MIMEJSON = "application/json"
-	MIMEHTML = "text/html"
-	MIMEXML  = "application/xml"
-	MIMEXML2 = "text/xml"
+	MIMEJSON  = "application/json"
+	MIMEHTML  = "text/html"
+	MIMEXML   = "application/xml"
+	MIMEXML2  = "text/xml"
+	MIMEYAML  = "application/x-yaml"
+	MIMEYAML2 = "application/yaml"
+	MIMETOML  = "application/toml"
 )
 
 type Binding interface {
@@ -24,17 +32,54 @@ type BindingUri interface {
 	BindUri(map[string][]string, interface{}) error
 }
 
+// BindingWithValidator 由内置绑定实现，用指定的校验器代替全局 Validator 完成绑定
+type BindingWithValidator interface {
+	Binding
+	BindWithValidator(*http.Request, interface{}, StructValidator) error
+}
+
 type StructValidator interface {
 	ValidateStruct(interface{}) error
 	Engine() interface{}
 }
 
+type (
+	// FieldLevel 是自定义字段规则收到的参数
+	FieldLevel = validator.FieldLevel
+	// StructLevel 是结构体级别规则收到的参数
+	StructLevel = validator.StructLevel
+	// ValidationFunc 是自定义字段规则
+	ValidationFunc = validator.Func
+	// StructLevelFunc 是结构体级别规则
+	StructLevelFunc = validator.StructLevelFunc
+)
+
+// ValidatorRegistry 允许在不断言具体校验引擎的情况下注册自定义规则
+type ValidatorRegistry interface {
+	// RegisterValidation 注册 tag 对应的字段规则
+	RegisterValidation(tag string, fn ValidationFunc) error
+	// RegisterStructValidation 为 types 中的结构体类型注册结构体级别规则
+	RegisterStructValidation(fn StructLevelFunc, types ...interface{})
+	// RegisterAlias 注册规则别名，如 RegisterAlias("iscolor", "hexcolor|rgb|rgba")
+	RegisterAlias(alias, tags string)
+}
+
+// RegistryValidator 是可以注册自定义规则的 StructValidator
+type RegistryValidator interface {
+	StructValidator
+	ValidatorRegistry
+}
+
 var Validator StructValidator = &defaultValidator{}
 
+var errValidatorNotRegistry = errors.New("binding: Validator does not support custom rules")
+
 var (
 	JSON = jsonBinding{}
 	XML  = xmlBinding{}
 	Form = formBinding{}
+	YAML = yamlBinding{}
+	TOML = tomlBinding{}
 )
 
 func Default(method, contentType string) Binding {
@@ -47,12 +92,51 @@ func Default(method, contentType string) Binding {
 		return JSON
 	case MIMEXML, MIMEXML2:
 		return XML
+	case MIMEYAML, MIMEYAML2:
+		return YAML
+	case MIMETOML:
+		return TOML
+	default:
+		return Form
+	}
+}
+
+// RegisterValidation 在全局 Validator 上注册字段规则
+func RegisterValidation(tag string, fn ValidationFunc) error {
+	r, ok := Validator.(ValidatorRegistry)
+	if !ok {
+		return errValidatorNotRegistry
+	}
+	return r.RegisterValidation(tag, fn)
+}
+
+// RegisterStructValidation 在全局 Validator 上注册结构体级别规则
+func RegisterStructValidation(fn StructLevelFunc, types ...interface{}) error {
+	r, ok := Validator.(ValidatorRegistry)
+	if !ok {
+		return errValidatorNotRegistry
+	}
+	r.RegisterStructValidation(fn, types...)
+	return nil
+}
+
+// RegisterAlias 在全局 Validator 上注册规则别名
+func RegisterAlias(alias, tags string) error {
+	r, ok := Validator.(ValidatorRegistry)
+	if !ok {
+		return errValidatorNotRegistry
 	}
+	r.RegisterAlias(alias, tags)
+	return nil
 }
 
 func validate(obj interface{}) error {
-	if Validator == nil {
+	return validateWith(Validator, obj)
+}
+
+func validateWith(v StructValidator, obj interface{}) error {
+	if v == nil {
 		return nil
 	}
-	return Validator.ValidateStruct(obj)
+	return v.ValidateStruct(obj)
 }
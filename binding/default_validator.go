@@ -1,9 +1,12 @@
 package binding
 
 import (
-	"gopkg.in/go-playground/validator.v9"
+	"fmt"
 	"reflect"
+	"sort"
 	"sync"
+
+	"gopkg.in/go-playground/validator.v9"
 )
 
 type defaultValidator struct {
@@ -12,30 +15,113 @@ type defaultValidator struct {
 }
 
 var _ StructValidator = &defaultValidator{}
+var _ ValidatorRegistry = &defaultValidator{}
+
+// NewValidator 返回一个独立的默认校验器，可以通过 Engine.Validator 让每个 Engine 使用自己的规则
+func NewValidator() RegistryValidator {
+	return &defaultValidator{}
+}
 
+// ValidateStruct 校验结构体，对结构体（及其指针）的切片、数组和 map 会逐个元素校验
 func (v *defaultValidator) ValidateStruct(obj interface{}) error {
+	if obj == nil {
+		return nil
+	}
 	value := reflect.ValueOf(obj)
-	valueType := value.Kind()
-	if valueType == reflect.Ptr {
-		valueType = value.Elem().Kind()
+	switch value.Kind() {
+	case reflect.Ptr:
+		if value.IsNil() {
+			return nil
+		}
+		if value.Elem().Kind() == reflect.Struct {
+			return v.validateStruct(obj)
+		}
+		return v.ValidateStruct(value.Elem().Interface())
+	case reflect.Struct:
+		return v.validateStruct(obj)
+	case reflect.Slice, reflect.Array:
+		var errs ValidationErrors
+		for i := 0; i < value.Len(); i++ {
+			if err := v.ValidateStruct(value.Index(i).Interface()); err != nil {
+				ve, ok := err.(ValidationErrors)
+				if !ok {
+					return err
+				}
+				errs = append(errs, ve.withPrefix(fmt.Sprintf("[%d]", i))...)
+			}
+		}
+		if len(errs) > 0 {
+			return errs
+		}
+	case reflect.Map:
+		keys := value.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+		})
+		var errs ValidationErrors
+		for _, key := range keys {
+			if err := v.ValidateStruct(value.MapIndex(key).Interface()); err != nil {
+				ve, ok := err.(ValidationErrors)
+				if !ok {
+					return err
+				}
+				errs = append(errs, ve.withPrefix(fmt.Sprintf("[%v]", key.Interface()))...)
+			}
+		}
+		if len(errs) > 0 {
+			return errs
+		}
 	}
-	if valueType == reflect.Struct {
-		v.lazyinit()
-		if err := v.validate.Struct(obj); err != nil {
-			return err
+	return nil
+}
+
+func (v *defaultValidator) validateStruct(obj interface{}) error {
+	v.lazyinit()
+	if err := v.validate.Struct(obj); err != nil {
+		if errs, ok := err.(validator.ValidationErrors); ok {
+			return newValidationErrors(errs, structTypeName(obj))
 		}
+		return err
 	}
 	return nil
 }
 
+// structTypeName 返回 obj 解引用后的类型名，匿名结构体返回空字符串
+func structTypeName(obj interface{}) string {
+	t := reflect.TypeOf(obj)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil {
+		return ""
+	}
+	return t.Name()
+}
+
 func (v *defaultValidator) Engine() interface{} {
 	v.lazyinit()
 	return v.validate
 }
 
+func (v *defaultValidator) RegisterValidation(tag string, fn ValidationFunc) error {
+	v.lazyinit()
+	return v.validate.RegisterValidation(tag, fn)
+}
+
+func (v *defaultValidator) RegisterStructValidation(fn StructLevelFunc, types ...interface{}) {
+	v.lazyinit()
+	v.validate.RegisterStructValidation(fn, types...)
+}
+
+func (v *defaultValidator) RegisterAlias(alias, tags string) {
+	v.lazyinit()
+	v.validate.RegisterAlias(alias, tags)
+}
+
 func (v *defaultValidator) lazyinit() {
 	v.once.Do(func() {
 		v.validate = validator.New()
 		v.validate.SetTagName("binding")
+		v.validate.RegisterTagNameFunc(fieldName)
 	})
 }
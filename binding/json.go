@@ -4,7 +4,8 @@ import (
 	"bytes"
 	"fmt"
 	"io"
-	"jin/internal"
+	"io/ioutil"
+	"jin/internal/json"
 	"net/http"
 )
 
@@ -22,14 +23,31 @@ func (jsonBinding) Bind(req *http.Request, obj interface{}) error {
 	if req == nil || req.Body == nil {
 		return fmt.Errorf("invalid request")
 	}
-	return decodeJSON(req.Body, obj)
+	return decodeJSON(req.Body, obj, Validator)
+}
+
+func (jsonBinding) BindWithValidator(req *http.Request, obj interface{}, v StructValidator) error {
+	if req == nil || req.Body == nil {
+		return fmt.Errorf("invalid request")
+	}
+	return decodeJSON(req.Body, obj, v)
 }
 
 func (jsonBinding) BindBody(body []byte, obj interface{}) error {
-	return decodeJSON(bytes.NewReader(body), obj)
+	return decodeJSON(bytes.NewReader(body), obj, Validator)
 }
 
-func decodeJSON(r io.Reader, obj interface{}) error {
+func decodeJSON(r io.Reader, obj interface{}, v StructValidator) error {
+	if MaxJSONDepth > 0 {
+		data, err := ioutil.ReadAll(r)
+		if err != nil {
+			return AsLimitError(err)
+		}
+		if err := checkJSONDepth(data, MaxJSONDepth); err != nil {
+			return err
+		}
+		r = bytes.NewReader(data)
+	}
 	decoder := json.NewDecoder(r)
 	if EnableDecoderUseNumber {
 		decoder.UseNumber()
@@ -38,7 +56,7 @@ func decodeJSON(r io.Reader, obj interface{}) error {
 		decoder.DisallowUnknownFields()
 	}
 	if err := decoder.Decode(obj); err != nil {
-		return err
+		return AsLimitError(err)
 	}
-	return validate(obj)
+	return validateWith(v, obj)
 }
@@ -0,0 +1,124 @@
+package binding
+
+import (
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+)
+
+// MaxJSONDepth 限制 JSON 请求体中对象和数组的最大嵌套层数，0 表示不限制
+var MaxJSONDepth = 0
+
+// MaxFormFields 限制表单中字段的最大数量，0 表示不限制
+// 它只是解析之后的检查：表单被完整读入内存或临时文件之后才计数，不能防止解析时的资源耗尽，
+// 限制资源占用需要使用 MaxBodyBytes
+var MaxFormFields = 0
+
+// MaxFormFiles 限制 multipart 表单中文件的最大数量，0 表示不限制
+// 与 MaxFormFields 一样只是解析之后的检查，限制资源占用需要使用 MaxBodyBytes
+var MaxFormFiles = 0
+
+// LimitKind 表示超出的是哪一种限制
+type LimitKind uint8
+
+const (
+	// LimitBodySize 请求体超过 MaxBodyBytes
+	LimitBodySize LimitKind = iota
+	// LimitJSONDepth JSON 嵌套超过 MaxJSONDepth
+	LimitJSONDepth
+	// LimitFormFields 表单字段数超过 MaxFormFields
+	LimitFormFields
+	// LimitFormFiles 表单文件数超过 MaxFormFiles
+	LimitFormFiles
+)
+
+func (k LimitKind) String() string {
+	switch k {
+	case LimitBodySize:
+		return "body size"
+	case LimitJSONDepth:
+		return "json depth"
+	case LimitFormFields:
+		return "form fields"
+	case LimitFormFiles:
+		return "form files"
+	}
+	return "unknown"
+}
+
+// LimitError 表示请求超出了解码限制，处理器可以据此与校验失败区分开
+type LimitError struct {
+	Kind  LimitKind
+	Limit int64
+}
+
+var _ error = &LimitError{}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("binding: request exceeds %s limit of %d", e.Kind, e.Limit)
+}
+
+// AsLimitError 把 http.MaxBytesReader 返回的错误转换为 *LimitError，其他错误原样返回
+func AsLimitError(err error) error {
+	var mbe *http.MaxBytesError
+	if errors.As(err, &mbe) {
+		return &LimitError{Kind: LimitBodySize, Limit: mbe.Limit}
+	}
+	return err
+}
+
+// checkJSONDepth 扫描 JSON 文本，嵌套层数超过 max 时返回 *LimitError
+func checkJSONDepth(data []byte, max int) error {
+	depth := 0
+	inString, escaped := false, false
+	for _, b := range data {
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case b == '\\':
+				escaped = true
+			case b == '"':
+				inString = false
+			}
+			continue
+		}
+		switch b {
+		case '"':
+			inString = true
+		case '{', '[':
+			depth++
+			if depth > max {
+				return &LimitError{Kind: LimitJSONDepth, Limit: int64(max)}
+			}
+		case '}', ']':
+			depth--
+		}
+	}
+	return nil
+}
+
+// CheckFormLimits 检查已解析表单的字段数和文件数是否超过 MaxFormFields 和 MaxFormFiles
+func CheckFormLimits(form url.Values, mf *multipart.Form) error {
+	if MaxFormFields > 0 {
+		n := 0
+		for _, values := range form {
+			n += len(values)
+		}
+		if n > MaxFormFields {
+			return &LimitError{Kind: LimitFormFields, Limit: int64(MaxFormFields)}
+		}
+	}
+	if MaxFormFiles > 0 && mf != nil {
+		n := 0
+		for _, files := range mf.File {
+			n += len(files)
+		}
+		if n > MaxFormFiles {
+			return &LimitError{Kind: LimitFormFiles, Limit: int64(MaxFormFiles)}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package binding
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+type tomlBinding struct{}
+
+func (tomlBinding) Name() string {
+	return "toml"
+}
+
+func (tomlBinding) Bind(req *http.Request, obj interface{}) error {
+	if req == nil || req.Body == nil {
+		return fmt.Errorf("invalid request")
+	}
+	return decodeTOML(req.Body, obj, Validator)
+}
+
+func (tomlBinding) BindWithValidator(req *http.Request, obj interface{}, v StructValidator) error {
+	if req == nil || req.Body == nil {
+		return fmt.Errorf("invalid request")
+	}
+	return decodeTOML(req.Body, obj, v)
+}
+
+func (tomlBinding) BindBody(body []byte, obj interface{}) error {
+	return decodeTOML(bytes.NewReader(body), obj, Validator)
+}
+
+func decodeTOML(r io.Reader, obj interface{}, v StructValidator) error {
+	decoder := toml.NewDecoder(r)
+	if EnableDecoderDisallowUnknownFields {
+		decoder.DisallowUnknownFields()
+	}
+	if err := decoder.Decode(obj); err != nil {
+		return AsLimitError(err)
+	}
+	return validateWith(v, obj)
+}
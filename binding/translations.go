@@ -0,0 +1,124 @@
+package binding
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Translator 把一个字段校验错误翻译为可读的消息
+type Translator interface {
+	Translate(fe FieldError) string
+}
+
+// TranslatorFunc 让普通函数可以作为 Translator 使用
+type TranslatorFunc func(fe FieldError) string
+
+func (f TranslatorFunc) Translate(fe FieldError) string {
+	return f(fe)
+}
+
+// MessageTranslator 基于消息模板的翻译器，键为校验规则
+// 模板中的 {field} 和 {param} 会被替换为字段路径和规则参数
+// 对字符串、切片和 map 的长度规则，会优先查找 "规则.len" 的模板，如 "min.len"
+type MessageTranslator struct {
+	Messages map[string]string
+	// Fallback 在没有对应规则的模板时使用
+	Fallback string
+}
+
+var _ Translator = &MessageTranslator{}
+
+func (t *MessageTranslator) Translate(fe FieldError) string {
+	tmpl, ok := "", false
+	switch fe.Kind {
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+		tmpl, ok = t.Messages[fe.Tag+".len"]
+	}
+	if !ok {
+		tmpl, ok = t.Messages[fe.Tag]
+	}
+	if !ok {
+		tmpl = t.Fallback
+	}
+	return strings.NewReplacer("{field}", fe.Field, "{param}", fe.Param, "{tag}", fe.Tag).Replace(tmpl)
+}
+
+var (
+	translatorsMu sync.RWMutex
+	translators   = map[string]Translator{
+		"en": enTranslator,
+		"zh": zhTranslator,
+	}
+)
+
+// RegisterTranslator 注册或替换 lang 对应的翻译器
+func RegisterTranslator(lang string, t Translator) {
+	translatorsMu.Lock()
+	defer translatorsMu.Unlock()
+	translators[lang] = t
+}
+
+// GetTranslator 返回 lang 对应的翻译器
+func GetTranslator(lang string) (Translator, bool) {
+	translatorsMu.RLock()
+	defer translatorsMu.RUnlock()
+	t, ok := translators[lang]
+	return t, ok
+}
+
+var enTranslator = &MessageTranslator{
+	Messages: map[string]string{
+		"required": "{field} is required",
+		"email":    "{field} must be a valid email address",
+		"url":      "{field} must be a valid URL",
+		"uuid":     "{field} must be a valid UUID",
+		"alpha":    "{field} can only contain alphabetic characters",
+		"alphanum": "{field} can only contain alphanumeric characters",
+		"numeric":  "{field} must be a valid numeric value",
+		"len":      "{field} must be equal to {param}",
+		"len.len":  "{field} must be {param} in length",
+		"min":      "{field} must be {param} or greater",
+		"min.len":  "{field} must be at least {param} in length",
+		"max":      "{field} must be {param} or less",
+		"max.len":  "{field} must be at most {param} in length",
+		"eq":       "{field} must be equal to {param}",
+		"ne":       "{field} must not be equal to {param}",
+		"gt":       "{field} must be greater than {param}",
+		"gte":      "{field} must be greater than or equal to {param}",
+		"lt":       "{field} must be less than {param}",
+		"lte":      "{field} must be less than or equal to {param}",
+		"oneof":    "{field} must be one of [{param}]",
+		"eqfield":  "{field} must be equal to {param}",
+		"nefield":  "{field} must not be equal to {param}",
+	},
+	Fallback: "{field} failed on the '{tag}' rule",
+}
+
+var zhTranslator = &MessageTranslator{
+	Messages: map[string]string{
+		"required": "{field}为必填字段",
+		"email":    "{field}必须是一个有效的邮箱",
+		"url":      "{field}必须是一个有效的URL",
+		"uuid":     "{field}必须是一个有效的UUID",
+		"alpha":    "{field}只能包含字母",
+		"alphanum": "{field}只能包含字母和数字",
+		"numeric":  "{field}必须是一个有效的数值",
+		"len":      "{field}必须等于{param}",
+		"len.len":  "{field}长度必须是{param}",
+		"min":      "{field}最小只能为{param}",
+		"min.len":  "{field}长度必须至少为{param}",
+		"max":      "{field}必须小于或等于{param}",
+		"max.len":  "{field}长度不能超过{param}",
+		"eq":       "{field}必须等于{param}",
+		"ne":       "{field}不能等于{param}",
+		"gt":       "{field}必须大于{param}",
+		"gte":      "{field}必须大于或等于{param}",
+		"lt":       "{field}必须小于{param}",
+		"lte":      "{field}必须小于或等于{param}",
+		"oneof":    "{field}必须是[{param}]中的一个",
+		"eqfield":  "{field}必须等于{param}",
+		"nefield":  "{field}不能等于{param}",
+	},
+	Fallback: "{field}未通过'{tag}'校验",
+}
@@ -0,0 +1,109 @@
+package binding
+
+import (
+	"reflect"
+	"strings"
+
+	"gopkg.in/go-playground/validator.v9"
+)
+
+// FieldError 描述单个字段的校验失败
+type FieldError struct {
+	// Field 是字段路径，字段名取自 json/form tag，如 user.emails[0]
+	Field string `json:"field"`
+	// Tag 是失败的校验规则，如 required、min
+	Tag string `json:"tag"`
+	// Param 是规则的参数，如 min=3 中的 3
+	Param string `json:"param,omitempty"`
+	// Value 是字段的实际值
+	Value interface{} `json:"-"`
+	// Kind 是字段的类型，翻译器可以据此区分长度和数值
+	Kind reflect.Kind `json:"-"`
+}
+
+// ValidationErrors 是 defaultValidator 返回的结构化校验错误
+type ValidationErrors []FieldError
+
+var _ error = ValidationErrors{}
+
+func (ve ValidationErrors) Error() string {
+	return strings.Join(ve.Translate("en"), "; ")
+}
+
+// Translate 使用 lang 对应的翻译器把每个字段错误翻译成消息，保持原有顺序
+// 找不到 lang 对应的翻译器时使用英文
+func (ve ValidationErrors) Translate(lang string) []string {
+	t, ok := GetTranslator(lang)
+	if !ok {
+		t, _ = GetTranslator("en")
+	}
+	msgs := make([]string, len(ve))
+	for i, fe := range ve {
+		msgs[i] = t.Translate(fe)
+	}
+	return msgs
+}
+
+// TranslateMap 与 Translate 相同，但以字段路径为键返回，便于直接渲染为 JSON
+func (ve ValidationErrors) TranslateMap(lang string) map[string]string {
+	msgs := ve.Translate(lang)
+	m := make(map[string]string, len(ve))
+	for i, fe := range ve {
+		if _, ok := m[fe.Field]; !ok {
+			m[fe.Field] = msgs[i]
+		}
+	}
+	return m
+}
+
+// withPrefix 为每个字段路径加上前缀，用于切片和 map 元素的错误
+func (ve ValidationErrors) withPrefix(prefix string) ValidationErrors {
+	out := make(ValidationErrors, len(ve))
+	for i, fe := range ve {
+		if strings.HasPrefix(fe.Field, "[") {
+			fe.Field = prefix + fe.Field
+		} else {
+			fe.Field = prefix + "." + fe.Field
+		}
+		out[i] = fe
+	}
+	return out
+}
+
+// newValidationErrors 转换校验错误，typeName 是被校验的顶层结构体的类型名，匿名结构体为空
+func newValidationErrors(errs validator.ValidationErrors, typeName string) ValidationErrors {
+	ve := make(ValidationErrors, len(errs))
+	for i, fe := range errs {
+		ve[i] = FieldError{
+			Field: trimNamespace(fe.Namespace(), typeName),
+			Tag:   fe.Tag(),
+			Param: fe.Param(),
+			Value: fe.Value(),
+			Kind:  fe.Kind(),
+		}
+	}
+	return ve
+}
+
+// trimNamespace 去掉命名空间里顶层结构体的类型名，User.emails[0] -> emails[0]
+// 匿名结构体没有类型名，命名空间直接从字段开始，不能去掉第一段
+func trimNamespace(ns, typeName string) string {
+	if typeName != "" && strings.HasPrefix(ns, typeName+".") {
+		return ns[len(typeName)+1:]
+	}
+	return strings.TrimPrefix(ns, ".")
+}
+
+// fieldName 优先使用 json tag，其次 form tag 作为错误里的字段名
+func fieldName(fld reflect.StructField) string {
+	for _, key := range []string{"json", "form"} {
+		name := strings.SplitN(fld.Tag.Get(key), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return ""
+}
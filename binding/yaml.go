@@ -0,0 +1,51 @@
+package binding
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"gopkg.in/yaml.v3"
+)
+
+type yamlBinding struct{}
+
+func (yamlBinding) Name() string {
+	return "yaml"
+}
+
+func (yamlBinding) Bind(req *http.Request, obj interface{}) error {
+	if req == nil || req.Body == nil {
+		return fmt.Errorf("invalid request")
+	}
+	return decodeYAML(req.Body, obj, Validator)
+}
+
+func (yamlBinding) BindWithValidator(req *http.Request, obj interface{}, v StructValidator) error {
+	if req == nil || req.Body == nil {
+		return fmt.Errorf("invalid request")
+	}
+	return decodeYAML(req.Body, obj, v)
+}
+
+func (yamlBinding) BindBody(body []byte, obj interface{}) error {
+	return decodeYAML(bytes.NewReader(body), obj, Validator)
+}
+
+func decodeYAML(r io.Reader, obj interface{}, v StructValidator) error {
+	// yaml.v3 会把读取错误转换为文本，先读出请求体才能识别 *LimitError
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return AsLimitError(err)
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	if EnableDecoderDisallowUnknownFields {
+		decoder.KnownFields(true)
+	}
+	if err := decoder.Decode(obj); err != nil {
+		return err
+	}
+	return validateWith(v, obj)
+}
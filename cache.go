@@ -0,0 +1,405 @@
+package jin
+
+import (
+	"bufio"
+	"bytes"
+	"container/list"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CachedResponse 是缓存中保存的完整响应
+type CachedResponse struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// CacheStore 保存缓存的响应，可以替换为外部存储实现
+type CacheStore interface {
+	// Get 返回 key 对应的未过期响应
+	Get(key string) (*CachedResponse, bool)
+	// Set 保存响应，ttl 后过期
+	Set(key string, resp *CachedResponse, ttl time.Duration)
+	// Delete 删除 key 对应的响应
+	Delete(key string)
+}
+
+// CacheConfig 定义 Cache 中间件的配置
+type CacheConfig struct {
+	// TTL 是响应默认的缓存时间，响应的 Cache-Control: max-age/s-maxage 会覆盖它
+	TTL time.Duration
+
+	// Store 保存缓存的响应
+	// 可选。默认值为 NewMemoryCacheStore(1024)
+	Store CacheStore
+
+	// VaryHeaders 是参与缓存键计算的请求头，如 Accept-Language
+	// 响应的 Vary 中列出了不在 VaryHeaders 中的请求头时不会被缓存，
+	// 例如在 Compress 之前使用时需要包含 Accept-Encoding 才能缓存压缩后的响应
+	// 可选。
+	VaryHeaders []string
+}
+
+// Cache 返回一个使用内存 LRU 存储的响应缓存中间件
+func Cache(ttl time.Duration) HandlerFunc {
+	return CacheWithConfig(CacheConfig{TTL: ttl})
+}
+
+// CacheWithConfig 返回一个缓存 GET 和 HEAD 响应的中间件
+// 缓存键由方法、路径、查询参数和 VaryHeaders 组成。请求的 Cache-Control: no-store 跳过缓存，
+// no-cache 跳过读取；响应的 no-store、no-cache、private 或 Set-Cookie 不会被缓存，
+// Vary 中包含不在 VaryHeaders 中的请求头时也不会被缓存。
+// 带有 Authorization 的请求不读取缓存，响应只有在 Cache-Control 包含 public 或 s-maxage 时才会被缓存。
+// 同一个键的并发未命中只会执行一次处理器，其他请求等待并共享它的结果
+func CacheWithConfig(conf CacheConfig) HandlerFunc {
+	assert1(conf.TTL > 0, "cache ttl must be greater than 0")
+	if conf.Store == nil {
+		conf.Store = NewMemoryCacheStore(1024)
+	}
+	varyHeaders := make(map[string]struct{}, len(conf.VaryHeaders))
+	for _, h := range conf.VaryHeaders {
+		varyHeaders[http.CanonicalHeaderKey(h)] = struct{}{}
+	}
+	var group cacheGroup
+
+	return func(c *Context) {
+		if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+			c.Next()
+			return
+		}
+		reqCC := parseCacheControl(c.requestHeader("Cache-Control"))
+		if _, ok := reqCC["no-store"]; ok {
+			c.Next()
+			return
+		}
+
+		key := cacheKey(c.Request, conf.VaryHeaders)
+		if c.requestHeader("Authorization") != "" {
+			// 认证请求的响应可能因用户而异，不读取缓存也不参与合并
+			cw := newCacheWriter(c.Writer)
+			c.Writer = cw
+			defer func() {
+				c.Writer = cw.ResponseWriter
+			}()
+			c.Next()
+			if cw.hijacked {
+				return
+			}
+			if resp, ttl, ok := cw.cachedResponse(conf.TTL, varyHeaders, true); ok {
+				conf.Store.Set(key, resp, ttl)
+			}
+			return
+		}
+		if _, ok := reqCC["no-cache"]; !ok {
+			if resp, ok := conf.Store.Get(key); ok {
+				serveCached(c, resp)
+				return
+			}
+		}
+
+		call, leader := group.join(key)
+		if !leader {
+			call.wg.Wait()
+			if call.resp != nil {
+				serveCached(c, call.resp)
+				return
+			}
+			// 领头请求的响应不可缓存，自己处理
+			c.Next()
+			return
+		}
+		defer group.done(key, call)
+
+		c.Header("X-Cache", "MISS")
+		cw := newCacheWriter(c.Writer)
+		c.Writer = cw
+		defer func() {
+			c.Writer = cw.ResponseWriter
+		}()
+		c.Next()
+
+		if cw.hijacked {
+			return
+		}
+		if resp, ttl, ok := cw.cachedResponse(conf.TTL, varyHeaders, false); ok {
+			conf.Store.Set(key, resp, ttl)
+			call.resp = resp
+		}
+	}
+}
+
+func serveCached(c *Context, resp *CachedResponse) {
+	header := c.Writer.Header()
+	for k, v := range resp.Header {
+		header[k] = v
+	}
+	header.Set("X-Cache", "HIT")
+	c.Writer.WriteHeader(resp.Status)
+	if c.Request.Method == http.MethodHead || len(resp.Body) == 0 {
+		c.Writer.WriteHeaderNow()
+	} else {
+		c.Writer.Write(resp.Body)
+	}
+	c.Abort()
+}
+
+func cacheKey(req *http.Request, varyHeaders []string) string {
+	var b strings.Builder
+	b.WriteString(req.Method)
+	b.WriteByte(' ')
+	b.WriteString(req.URL.Path)
+	if query := req.URL.Query(); len(query) > 0 {
+		// Encode 会按键排序，参数顺序不同的请求使用同一个键
+		b.WriteByte('?')
+		b.WriteString(query.Encode())
+	}
+	for _, h := range varyHeaders {
+		b.WriteByte('\n')
+		b.WriteString(h)
+		b.WriteByte(':')
+		b.WriteString(req.Header.Get(h))
+	}
+	return b.String()
+}
+
+func parseCacheControl(value string) map[string]string {
+	cc := make(map[string]string)
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, val := part, ""
+		if i := strings.IndexByte(part, '='); i >= 0 {
+			name, val = part[:i], strings.Trim(part[i+1:], `"`)
+		}
+		cc[strings.ToLower(name)] = val
+	}
+	return cc
+}
+
+// cacheWriter 在写给客户端的同时保存一份响应体
+type cacheWriter struct {
+	ResponseWriter
+	body     bytes.Buffer
+	hijacked bool
+	// before 是处理器执行前已经设置的响应头，它们属于当前请求，不应该被缓存
+	before http.Header
+}
+
+// cachePerRequestHeaders 是每个请求都不同的响应头，即使由下游处理器设置也不缓存
+var cachePerRequestHeaders = []string{
+	"X-Cache",
+	HeaderXRequestID,
+	"X-RateLimit-Limit",
+	"X-RateLimit-Remaining",
+	"X-RateLimit-Reset",
+	"Retry-After",
+	"Content-Security-Policy",
+	"Date",
+	HeaderTraceparent,
+	HeaderTracestate,
+}
+
+func newCacheWriter(w ResponseWriter) *cacheWriter {
+	return &cacheWriter{ResponseWriter: w, before: w.Header().Clone()}
+}
+
+var _ ResponseWriter = &cacheWriter{}
+
+func (w *cacheWriter) Write(data []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(data)
+	w.body.Write(data[:n])
+	return n, err
+}
+
+func (w *cacheWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *cacheWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.hijacked = true
+	return w.ResponseWriter.Hijack()
+}
+
+// cachedResponse 根据状态码、响应的 Cache-Control 和 Vary 判断是否可以缓存，并返回缓存时间
+// authorized 表示请求带有 Authorization，此时只缓存明确声明 public 或 s-maxage 的响应
+func (w *cacheWriter) cachedResponse(ttl time.Duration, varyHeaders map[string]struct{}, authorized bool) (*CachedResponse, time.Duration, bool) {
+	if w.Status() != http.StatusOK {
+		return nil, 0, false
+	}
+	header := w.Header()
+	if header.Get("Set-Cookie") != "" {
+		return nil, 0, false
+	}
+	cc := parseCacheControl(header.Get("Cache-Control"))
+	for _, directive := range []string{"no-store", "no-cache", "private"} {
+		if _, ok := cc[directive]; ok {
+			return nil, 0, false
+		}
+	}
+	if authorized {
+		_, public := cc["public"]
+		_, sMaxAge := cc["s-maxage"]
+		if !public && !sMaxAge {
+			return nil, 0, false
+		}
+	}
+	// 缓存键不包含的请求头会让不同的请求拿到错误的变体
+	for _, v := range varyValues(header) {
+		if _, ok := varyHeaders[http.CanonicalHeaderKey(v)]; !ok {
+			return nil, 0, false
+		}
+	}
+	for _, directive := range []string{"s-maxage", "max-age"} {
+		if v, ok := cc[directive]; ok {
+			if secs, err := strconv.Atoi(v); err == nil {
+				ttl = time.Duration(secs) * time.Second
+				break
+			}
+		}
+	}
+	if ttl <= 0 {
+		return nil, 0, false
+	}
+
+	// 只保存下游处理器新增或修改的响应头，命中时不会覆盖其他中间件为当前请求设置的值
+	saved := make(http.Header, len(header))
+	for k, v := range header {
+		if old, ok := w.before[k]; ok && equalHeaderValues(old, v) {
+			continue
+		}
+		saved[k] = append([]string(nil), v...)
+	}
+	for _, k := range cachePerRequestHeaders {
+		saved.Del(k)
+	}
+	return &CachedResponse{
+		Status: w.Status(),
+		Header: saved,
+		Body:   append([]byte(nil), w.body.Bytes()...),
+	}, ttl, true
+}
+
+// cacheCall 是一次正在进行的未命中处理
+type cacheCall struct {
+	wg   sync.WaitGroup
+	resp *CachedResponse
+}
+
+// cacheGroup 合并同一个键的并发未命中
+type cacheGroup struct {
+	mu    sync.Mutex
+	calls map[string]*cacheCall
+}
+
+// join 返回 key 对应的处理，第一个到达的请求是领头者
+func (g *cacheGroup) join(key string) (*cacheCall, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.calls == nil {
+		g.calls = make(map[string]*cacheCall)
+	}
+	if call, ok := g.calls[key]; ok {
+		return call, false
+	}
+	call := &cacheCall{}
+	call.wg.Add(1)
+	g.calls[key] = call
+	return call, true
+}
+
+func (g *cacheGroup) done(key string, call *cacheCall) {
+	g.mu.Lock()
+	delete(g.calls, key)
+	g.mu.Unlock()
+	call.wg.Done()
+}
+
+// MemoryCacheStore 是进程内的 LRU 缓存存储
+type MemoryCacheStore struct {
+	mu       sync.Mutex
+	capacity int
+	ll       *list.List
+	items    map[string]*list.Element
+}
+
+type memoryCacheEntry struct {
+	key     string
+	resp    *CachedResponse
+	expires time.Time
+}
+
+var _ CacheStore = &MemoryCacheStore{}
+
+// NewMemoryCacheStore 返回一个最多保存 capacity 个响应的 LRU 存储
+func NewMemoryCacheStore(capacity int) *MemoryCacheStore {
+	assert1(capacity > 0, "cache capacity must be greater than 0")
+	return &MemoryCacheStore{
+		capacity: capacity,
+		ll:       list.New(),
+		items:    make(map[string]*list.Element),
+	}
+}
+
+func (s *MemoryCacheStore) Get(key string) (*CachedResponse, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	elem, ok := s.items[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*memoryCacheEntry)
+	if time.Now().After(entry.expires) {
+		s.removeElement(elem)
+		return nil, false
+	}
+	s.ll.MoveToFront(elem)
+	return entry.resp, true
+}
+
+func (s *MemoryCacheStore) Set(key string, resp *CachedResponse, ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	expires := time.Now().Add(ttl)
+	if elem, ok := s.items[key]; ok {
+		entry := elem.Value.(*memoryCacheEntry)
+		entry.resp, entry.expires = resp, expires
+		s.ll.MoveToFront(elem)
+		return
+	}
+	s.items[key] = s.ll.PushFront(&memoryCacheEntry{key: key, resp: resp, expires: expires})
+	for s.ll.Len() > s.capacity {
+		s.removeElement(s.ll.Back())
+	}
+}
+
+func (s *MemoryCacheStore) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if elem, ok := s.items[key]; ok {
+		s.removeElement(elem)
+	}
+}
+
+func (s *MemoryCacheStore) removeElement(elem *list.Element) {
+	s.ll.Remove(elem)
+	delete(s.items, elem.Value.(*memoryCacheEntry).key)
+}
+
+func equalHeaderValues(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,323 @@
+package jin
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+)
+
+const (
+	// EncodingGzip gzip 压缩
+	EncodingGzip = "gzip"
+	// EncodingDeflate deflate 压缩
+	EncodingDeflate = "deflate"
+	// EncodingBrotli brotli 压缩
+	EncodingBrotli = "br"
+)
+
+// CompressConfig 定义 Compress 中间件的配置
+type CompressConfig struct {
+	// Level 是 gzip 和 deflate 的压缩级别
+	// 可选。默认值为 gzip.DefaultCompression
+	Level int
+
+	// BrotliLevel 是 brotli 的压缩级别
+	// 可选。默认值为 brotli.DefaultCompression
+	BrotliLevel int
+
+	// MinLength 是需要压缩的最小响应体字节数，更小的响应原样返回
+	// 可选。默认值为 1024
+	MinLength int
+
+	// Encodings 是支持的编码，客户端权重相同时按顺序优先
+	// 可选。默认值为 br、gzip、deflate
+	Encodings []string
+
+	// ExcludedContentTypes 是不需要压缩的 Content-Type 前缀
+	// 可选。默认排除图片、音视频和常见的压缩格式
+	ExcludedContentTypes []string
+
+	// ExcludedExtensions 是不需要压缩的请求路径扩展名，如 ".png"
+	// 可选。
+	ExcludedExtensions []string
+
+	// ExcludedPaths 是不需要压缩的请求路径前缀
+	// 可选。
+	ExcludedPaths []string
+}
+
+var defaultExcludedContentTypes = []string{
+	"image/png", "image/jpeg", "image/gif", "image/webp", "image/avif",
+	"video/", "audio/", "font/woff",
+	"application/zip", "application/gzip", "application/x-gzip", "application/x-brotli",
+	"application/x-7z-compressed", "application/x-rar-compressed", "application/octet-stream",
+	"text/event-stream",
+}
+
+// Compress 返回一个使用默认配置的响应压缩中间件
+func Compress() HandlerFunc {
+	return CompressWithConfig(CompressConfig{})
+}
+
+// CompressWithConfig 返回一个响应压缩中间件
+// 它根据 Accept-Encoding 协商编码，跳过过小、已经压缩或被排除的响应，
+// 压缩时设置 Vary: Accept-Encoding 并删除 Content-Length
+func CompressWithConfig(conf CompressConfig) HandlerFunc {
+	if conf.Level == 0 {
+		conf.Level = gzip.DefaultCompression
+	}
+	if conf.BrotliLevel == 0 {
+		conf.BrotliLevel = brotli.DefaultCompression
+	}
+	if conf.MinLength == 0 {
+		conf.MinLength = 1024
+	}
+	if len(conf.Encodings) == 0 {
+		conf.Encodings = []string{EncodingBrotli, EncodingGzip, EncodingDeflate}
+	}
+	if conf.ExcludedContentTypes == nil {
+		conf.ExcludedContentTypes = defaultExcludedContentTypes
+	}
+	pools := newEncoderPools(conf)
+
+	return func(c *Context) {
+		if !conf.shouldCompress(c.Request) {
+			c.Next()
+			return
+		}
+		addVary(c.Writer.Header(), "Accept-Encoding")
+		encoding := negotiateEncoding(c.requestHeader("Accept-Encoding"), conf.Encodings)
+		if encoding == "" {
+			c.Next()
+			return
+		}
+
+		cw := &compressWriter{
+			ResponseWriter: c.Writer,
+			conf:           &conf,
+			encoding:       encoding,
+			pool:           pools[encoding],
+		}
+		c.Writer = cw
+		defer func() {
+			cw.close()
+			c.Writer = cw.ResponseWriter
+		}()
+		c.Next()
+	}
+}
+
+func (conf *CompressConfig) shouldCompress(req *http.Request) bool {
+	if req.Method == http.MethodHead || req.Header.Get("Accept-Encoding") == "" {
+		return false
+	}
+	if strings.Contains(strings.ToLower(req.Header.Get("Connection")), "upgrade") {
+		return false
+	}
+	ext := path.Ext(req.URL.Path)
+	for _, e := range conf.ExcludedExtensions {
+		if ext == e {
+			return false
+		}
+	}
+	for _, p := range conf.ExcludedPaths {
+		if strings.HasPrefix(req.URL.Path, p) {
+			return false
+		}
+	}
+	return true
+}
+
+func (conf *CompressConfig) excludedContentType(contentType string) bool {
+	contentType = strings.ToLower(filterFlags(contentType))
+	for _, t := range conf.ExcludedContentTypes {
+		if strings.HasPrefix(contentType, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// negotiateEncoding 根据 Accept-Encoding 的 q 值从 supported 中选出编码，没有可用编码时返回 ""
+func negotiateEncoding(acceptEncoding string, supported []string) string {
+	best, bestQ := "", 0.0
+	weights := make(map[string]float64)
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, q := part, 1.0
+		if i := strings.IndexByte(part, ';'); i >= 0 {
+			name = strings.TrimSpace(part[:i])
+			param := strings.TrimSpace(part[i+1:])
+			if strings.HasPrefix(param, "q=") {
+				if v, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = v
+				}
+			}
+		}
+		weights[strings.ToLower(name)] = q
+	}
+	for _, enc := range supported {
+		q, ok := weights[enc]
+		if !ok {
+			q, ok = weights["*"]
+		}
+		if ok && q > bestQ {
+			best, bestQ = enc, q
+		}
+	}
+	return best
+}
+
+// encoder 是 gzip、flate 和 brotli writer 的公共方法
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(io.Writer)
+}
+
+func newEncoderPools(conf CompressConfig) map[string]*sync.Pool {
+	return map[string]*sync.Pool{
+		EncodingGzip: {New: func() interface{} {
+			w, err := gzip.NewWriterLevel(ioutil.Discard, conf.Level)
+			if err != nil {
+				panic(err)
+			}
+			return w
+		}},
+		EncodingDeflate: {New: func() interface{} {
+			w, err := flate.NewWriter(ioutil.Discard, conf.Level)
+			if err != nil {
+				panic(err)
+			}
+			return w
+		}},
+		EncodingBrotli: {New: func() interface{} {
+			return brotli.NewWriterLevel(ioutil.Discard, conf.BrotliLevel)
+		}},
+	}
+}
+
+// compressWriter 包装 ResponseWriter，在响应体达到 MinLength 后才决定是否压缩
+type compressWriter struct {
+	ResponseWriter
+	conf     *CompressConfig
+	encoding string
+	pool     *sync.Pool
+	encoder  encoder
+	buf      []byte
+	decided  bool
+}
+
+var _ ResponseWriter = &compressWriter{}
+
+func (w *compressWriter) Write(data []byte) (int, error) {
+	if !w.decided {
+		w.buf = append(w.buf, data...)
+		if len(w.buf) < w.conf.MinLength {
+			return len(data), nil
+		}
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+		return len(data), nil
+	}
+	if w.encoder != nil {
+		return w.encoder.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *compressWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// WriteHeaderNow 在写出响应头前先决定是否压缩，此时还没有响应体的话就不压缩
+func (w *compressWriter) WriteHeaderNow() {
+	if !w.decided {
+		w.decide(len(w.buf) >= w.conf.MinLength)
+	}
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+// Written 在响应体仍被缓冲时也返回 true，避免后续处理器覆盖已经开始的响应
+func (w *compressWriter) Written() bool {
+	return len(w.buf) > 0 || w.ResponseWriter.Written()
+}
+
+// Flush 用于流式响应，此时忽略 MinLength，只要内容类型允许就压缩
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		w.decide(true)
+	}
+	if w.encoder != nil {
+		w.encoder.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// Hijack 之后连接由调用者接管，不再压缩
+func (w *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.decided = true
+	w.buf = nil
+	return w.ResponseWriter.Hijack()
+}
+
+// decide 决定是否压缩并写出已缓冲的数据
+func (w *compressWriter) decide(compress bool) error {
+	w.decided = true
+	header := w.ResponseWriter.Header()
+	if header.Get("Content-Type") == "" && len(w.buf) > 0 {
+		header.Set("Content-Type", http.DetectContentType(w.buf))
+	}
+	if compress && (!bodyAllowedForStatus(w.ResponseWriter.Status()) ||
+		header.Get("Content-Encoding") != "" ||
+		w.conf.excludedContentType(header.Get("Content-Type"))) {
+		compress = false
+	}
+
+	if compress {
+		header.Set("Content-Encoding", w.encoding)
+		header.Del("Content-Length")
+		w.encoder = w.pool.Get().(encoder)
+		w.encoder.Reset(w.ResponseWriter)
+	}
+
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	var err error
+	if w.encoder != nil {
+		_, err = w.encoder.Write(buf)
+	} else {
+		_, err = w.ResponseWriter.Write(buf)
+	}
+	return err
+}
+
+// close 写出剩余的缓冲数据，结束压缩流并把 encoder 放回池中
+func (w *compressWriter) close() {
+	if !w.decided {
+		w.decide(false)
+	}
+	if w.encoder != nil {
+		w.encoder.Close()
+		w.encoder.Reset(ioutil.Discard)
+		w.pool.Put(w.encoder)
+		w.encoder = nil
+	}
+}
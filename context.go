@@ -1,9 +1,12 @@
 package jin
 
 import (
+	"fmt"
 	"io"
 	"io/ioutil"
 	"jin/binding"
+	"jin/render"
+	"log/slog"
 	"math"
 	"mime/multipart"
 	"net"
@@ -39,6 +42,10 @@ type Context struct {
 	queryCache url.Values
 
 	formCache url.Values
+
+	// maxBodyBytes 是当前路由的请求体上限，0 表示使用 Engine.MaxBodyBytes，负数表示不限制
+	maxBodyBytes int64
+	bodyLimited  bool
 }
 
 func (c *Context) reset() {
@@ -52,6 +59,8 @@ func (c *Context) reset() {
 	c.Accepted = nil
 	c.queryCache = nil
 	c.formCache = nil
+	c.maxBodyBytes = 0
+	c.bodyLimited = false
 }
 
 func (c *Context) Copy() *Context {
@@ -316,12 +325,22 @@ func (c *Context) PostFormArray(key string) []string {
 func (c *Context) getFormCache() {
 	if c.formCache == nil {
 		c.formCache = make(url.Values)
+		c.limitBody()
 		req := c.Request
 		if err := req.ParseMultipartForm(c.engine.MaxMultipartMemory); err != nil {
+			if le, ok := binding.AsLimitError(err).(*binding.LimitError); ok {
+				c.Error(le).SetType(ErrorTypeBind)
+				return
+			}
 			if err != http.ErrNotMultipart {
-				debugPrint("error on parse multipart form array: %v", err)
+				c.engine.debugPrint("error on parse multipart form array: %v", err)
 			}
 		}
+		// 超出限制时以 ErrorTypeBind 记录 *binding.LimitError，处理器可以从 c.Errors 中检测到
+		if err := binding.CheckFormLimits(req.PostForm, req.MultipartForm); err != nil {
+			c.Error(err).SetType(ErrorTypeBind)
+			return
+		}
 		c.formCache = req.PostForm
 	}
 }
@@ -361,7 +380,11 @@ func (c *Context) get(m map[string][]string, key string) (map[string]string, boo
 // FormFile 返回提供表单键的第一个文件
 func (c *Context) FormFile(name string) (*multipart.FileHeader, error) {
 	if c.Request.MultipartForm == nil {
+		c.limitBody()
 		if err := c.Request.ParseMultipartForm(c.engine.MaxMultipartMemory); err != nil {
+			return nil, binding.AsLimitError(err)
+		}
+		if err := binding.CheckFormLimits(c.Request.PostForm, c.Request.MultipartForm); err != nil {
 			return nil, err
 		}
 	}
@@ -370,8 +393,11 @@ func (c *Context) FormFile(name string) (*multipart.FileHeader, error) {
 }
 
 func (c *Context) MultipartFor() (*multipart.Form, error) {
-	err := c.Request.ParseMultipartForm(c.engine.MaxMultipartMemory)
-	return c.Request.MultipartForm, err
+	c.limitBody()
+	if err := c.Request.ParseMultipartForm(c.engine.MaxMultipartMemory); err != nil {
+		return c.Request.MultipartForm, binding.AsLimitError(err)
+	}
+	return c.Request.MultipartForm, binding.CheckFormLimits(c.Request.PostForm, c.Request.MultipartForm)
 }
 
 func (c *Context) SaveUploadedFile(file *multipart.FileHeader, dst string) error {
@@ -399,7 +425,14 @@ func (c *Context) Bind(object interface{}) error {
 
 func (c *Context) MustBindWith(obj interface{}, b binding.Binding) error {
 	if err := c.ShouldBindWith(obj, b); err != nil {
-		c.AbortWithError(http.StatusBadRequest, err).SetType(ErrorTypeBind)
+		code := http.StatusBadRequest
+		if _, ok := err.(*binding.LimitError); ok {
+			code = http.StatusRequestEntityTooLarge
+		}
+		e := c.AbortWithError(code, err).SetType(ErrorTypeBind)
+		if ve, ok := err.(binding.ValidationErrors); ok {
+			e.SetMeta(ve)
+		}
 		return err
 	}
 	return nil
@@ -410,10 +443,32 @@ func (c *Context) ShouldBind(obj interface{}) error {
 	return c.ShouldBindWith(obj, b)
 }
 
-func (c *Context) ShouldBindWith(obj interface{}, b binding.Binding) error {
+func (c *Context) ShouldBindWith(obj interface{}, b binding.Binding) (err error) {
+	span := c.Span().StartChild("bind " + b.Name())
+	defer func() {
+		span.RecordError(err)
+		span.Finish()
+	}()
+
+	c.limitBody()
+	if v := c.engine.Validator; v != nil {
+		if bv, ok := b.(binding.BindingWithValidator); ok {
+			return bv.BindWithValidator(c.Request, obj, v)
+		}
+	}
 	return b.Bind(c.Request, obj)
 }
 
+// AbortWithValidationErrors 以 400 中止请求，并把校验错误按 lang 翻译后以 JSON 输出
+// err 不是 binding.ValidationErrors 时直接输出 err.Error()
+func (c *Context) AbortWithValidationErrors(err error, lang string) {
+	if ve, ok := err.(binding.ValidationErrors); ok {
+		c.AbortWithStatusJSON(http.StatusBadRequest, H{"errors": ve.TranslateMap(lang)})
+		return
+	}
+	c.AbortWithStatusJSON(http.StatusBadRequest, H{"error": err.Error()})
+}
+
 // ClientIp 实现一个最佳算法用来返回真实的客户端 IP
 // 它解析 X-Real-IP 和 X-Forwarded-For 为的是在反向代理：如 nginx 或 haproxy 下工作
 // X-Forwarded-For 先于 X-Real-Ip 是因为 nginx 使用 X-Real-ip 来作为代理的 IP
@@ -441,6 +496,34 @@ func (c *Context) ClientIP() string {
 	return ""
 }
 
+// Logger 返回一个已经携带请求 ID、路由和客户端 IP 的结构化日志记录器
+// 它基于 Engine.Slog，未配置时基于 slog.Default()
+func (c *Context) Logger() *slog.Logger {
+	logger := c.engine.Slog
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return slog.New(logger.Handler().WithAttrs(c.slogAttrs()))
+}
+
+// slogAttrs 返回请求级别的日志属性
+func (c *Context) slogAttrs() []slog.Attr {
+	attrs := make([]slog.Attr, 0, 3)
+	if id := c.requestID(); id != "" {
+		attrs = append(attrs, slog.String("request_id", id))
+	}
+	return append(attrs,
+		slog.String("route", c.FullPath()),
+		slog.String("client_ip", c.ClientIP()),
+	)
+}
+
+// requestID 返回 RequestID 中间件保存的请求 ID，没有使用该中间件时返回空字符串
+// 不直接读取请求头，客户端传入的值只有经过 RequestID 中间件校验后才会写入日志
+func (c *Context) requestID() string {
+	return c.GetString(RequestIDKey)
+}
+
 func (c *Context) ContentType() string {
 	return filterFlags(c.requestHeader("Content-Type"))
 }
@@ -470,7 +553,16 @@ func bodyAllowedForStatus(status int) bool {
 }
 
 func (c *Context) Status(code int) {
-	c.writermem.WriteHeader(code)
+	c.Writer.WriteHeader(code)
+}
+
+// Header 是 c.Writer.Header().Set(key, value) 的快捷方式，value 为空时删除该响应头
+func (c *Context) Header(key, value string) {
+	if value == "" {
+		c.Writer.Header().Del(key)
+		return
+	}
+	c.Writer.Header().Set(key, value)
 }
 
 func (c *Context) GetHeader(key string) string {
@@ -478,7 +570,25 @@ func (c *Context) GetHeader(key string) string {
 }
 
 func (c *Context) GetRawData() ([]byte, error) {
-	return ioutil.ReadAll(c.Request.Body)
+	c.limitBody()
+	data, err := ioutil.ReadAll(c.Request.Body)
+	return data, binding.AsLimitError(err)
+}
+
+// limitBody 在第一次读取请求体前用 http.MaxBytesReader 包装它
+// 上限取自路由的 MaxBodyBytes 中间件，未设置时取 Engine.MaxBodyBytes
+func (c *Context) limitBody() {
+	if c.bodyLimited || c.Request == nil || c.Request.Body == nil {
+		return
+	}
+	c.bodyLimited = true
+	n := c.maxBodyBytes
+	if n == 0 {
+		n = c.engine.MaxBodyBytes
+	}
+	if n > 0 {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, n)
+	}
 }
 
 func (c *Context) SetCookie(name, value string, maxAge int, path, domain string, secure, httpOnly bool) {
@@ -496,6 +606,15 @@ func (c *Context) SetCookie(name, value string, maxAge int, path, domain string,
 	})
 }
 
+// SetCookieData 添加一个 Set-Cookie 头，可以设置 SameSite 等 SetCookie 不支持的属性
+// Path 为空时使用 "/"
+func (c *Context) SetCookieData(cookie *http.Cookie) {
+	if cookie.Path == "" {
+		cookie.Path = "/"
+	}
+	http.SetCookie(c.Writer, cookie)
+}
+
 func (c *Context) Cookie(name string) (string, error) {
 	cookie, err := c.Request.Cookie(name)
 	if err != nil {
@@ -505,6 +624,48 @@ func (c *Context) Cookie(name string) (string, error) {
 	return val, nil
 }
 
+// Render 写入响应头并调用 render.Render 渲染数据
+func (c *Context) Render(code int, r render.Render) {
+	c.Status(code)
+
+	if !bodyAllowedForStatus(code) {
+		r.WriteContentType(c.Writer)
+		c.Writer.WriteHeaderNow()
+		return
+	}
+
+	span := c.Span().StartChild("render")
+	defer span.Finish()
+	span.SetAttribute("render.type", fmt.Sprintf("%T", r))
+	if err := r.Render(c.Writer); err != nil {
+		span.RecordError(err)
+		panic(err)
+	}
+}
+
+// JSON 把给定的结构体序列化为 JSON 写入响应体，同时设置 Content-Type 为 "application/json"
+func (c *Context) JSON(code int, obj interface{}) {
+	c.Render(code, render.JSON{Data: obj})
+}
+
+// String 把给定的字符串写入响应体
+func (c *Context) String(code int, format string, values ...interface{}) {
+	c.Render(code, render.String{Format: format, Data: values})
+}
+
+// Data 把字节数据写入响应体并更新状态码
+func (c *Context) Data(code int, contentType string, data []byte) {
+	c.Render(code, render.Data{
+		ContentType: contentType,
+		Data:        data,
+	})
+}
+
+// IndentedJSON 与 JSON 相同，但输出带缩进的 JSON，会占用更多 CPU 和带宽
+func (c *Context) IndentedJSON(code int, obj interface{}) {
+	c.Render(code, render.IndentedJSON{Data: obj})
+}
+
 func (c *Context) File(filepath string) {
 	http.ServeFile(c.Writer, c.Request, filepath)
 }
@@ -0,0 +1,162 @@
+package jin
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSConfig 定义 CORS 中间件的配置
+type CORSConfig struct {
+	// AllowOrigins 是允许的来源，支持 "*"、精确匹配和 "https://*.example.com" 形式的子域名通配
+	AllowOrigins []string
+
+	// AllowOriginFunc 非 nil 时用它判断来源是否允许，AllowOrigins 将被忽略
+	AllowOriginFunc func(origin string) bool
+
+	// AllowMethods 是预检请求中允许的方法
+	AllowMethods []string
+
+	// AllowHeaders 是预检请求中允许的请求头，为空时原样返回预检请求要求的请求头
+	AllowHeaders []string
+
+	// ExposeHeaders 是允许浏览器读取的响应头
+	ExposeHeaders []string
+
+	// AllowCredentials 表示是否允许携带 cookie 等凭证
+	// 不能与 AllowOrigins 中的 "*" 同时使用，那样任何网站都能读取带凭证的响应，
+	// 确实需要时请使用 AllowOriginFunc 明确判断来源
+	AllowCredentials bool
+
+	// MaxAge 是预检结果可以被缓存的时间
+	MaxAge time.Duration
+}
+
+// DefaultCORSConfig 返回允许所有来源和常用方法的配置
+func DefaultCORSConfig() CORSConfig {
+	return CORSConfig{
+		AllowOrigins: []string{"*"},
+		AllowMethods: []string{
+			http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch,
+			http.MethodDelete, http.MethodHead, http.MethodOptions,
+		},
+		AllowHeaders: []string{"Origin", "Content-Length", "Content-Type"},
+		MaxAge:       12 * time.Hour,
+	}
+}
+
+type corsOrigins struct {
+	allowAll  bool
+	exact     map[string]struct{}
+	wildcards [][2]string
+	fn        func(origin string) bool
+}
+
+func newCORSOrigins(conf CORSConfig) corsOrigins {
+	o := corsOrigins{
+		exact: make(map[string]struct{}),
+		fn:    conf.AllowOriginFunc,
+	}
+	for _, origin := range conf.AllowOrigins {
+		origin = strings.ToLower(origin)
+		switch {
+		case origin == "*":
+			o.allowAll = true
+		case strings.Contains(origin, "*"):
+			parts := strings.SplitN(origin, "*", 2)
+			o.wildcards = append(o.wildcards, [2]string{parts[0], parts[1]})
+		default:
+			o.exact[origin] = struct{}{}
+		}
+	}
+	return o
+}
+
+func (o corsOrigins) allowed(origin string) bool {
+	if o.fn != nil {
+		return o.fn(origin)
+	}
+	if o.allowAll {
+		return true
+	}
+	origin = strings.ToLower(origin)
+	if _, ok := o.exact[origin]; ok {
+		return true
+	}
+	for _, w := range o.wildcards {
+		if len(origin) > len(w[0])+len(w[1]) && strings.HasPrefix(origin, w[0]) && strings.HasSuffix(origin, w[1]) {
+			return true
+		}
+	}
+	return false
+}
+
+// CORS 返回一个处理跨域资源共享的中间件
+// 预检请求由中间件直接以 204 应答并中止处理器链
+// 它需要通过 engine.Use 注册，这样没有 OPTIONS 处理器的路由在 HandleMethodNotAllowed 下
+// 也会先经过它，预检请求不会变成 405
+func CORS(conf CORSConfig) HandlerFunc {
+	assert1(len(conf.AllowOrigins) > 0 || conf.AllowOriginFunc != nil, "CORS: AllowOrigins or AllowOriginFunc must be set")
+
+	origins := newCORSOrigins(conf)
+	assert1(!(origins.allowAll && conf.AllowCredentials && conf.AllowOriginFunc == nil),
+		"CORS: AllowOrigins \"*\" can not be used with AllowCredentials, use AllowOriginFunc instead")
+	reflectOrigin := !origins.allowAll || conf.AllowCredentials || conf.AllowOriginFunc != nil
+	allowMethods := strings.Join(conf.AllowMethods, ", ")
+	allowHeaders := strings.Join(conf.AllowHeaders, ", ")
+	exposeHeaders := strings.Join(conf.ExposeHeaders, ", ")
+	maxAge := ""
+	if conf.MaxAge > 0 {
+		maxAge = strconv.FormatInt(int64(conf.MaxAge/time.Second), 10)
+	}
+
+	return func(c *Context) {
+		origin := c.requestHeader("Origin")
+		if origin == "" {
+			// 不是跨域请求
+			return
+		}
+		preflight := c.Request.Method == http.MethodOptions && c.requestHeader("Access-Control-Request-Method") != ""
+
+		if !origins.allowed(origin) {
+			if preflight {
+				c.AbortWithStatus(http.StatusForbidden)
+			}
+			return
+		}
+
+		header := c.Writer.Header()
+		if reflectOrigin {
+			header.Set("Access-Control-Allow-Origin", origin)
+			addVary(header, "Origin")
+		} else {
+			header.Set("Access-Control-Allow-Origin", "*")
+		}
+		if conf.AllowCredentials {
+			header.Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		if !preflight {
+			if exposeHeaders != "" {
+				header.Set("Access-Control-Expose-Headers", exposeHeaders)
+			}
+			return
+		}
+
+		addVary(header, "Access-Control-Request-Method")
+		addVary(header, "Access-Control-Request-Headers")
+		if allowMethods != "" {
+			header.Set("Access-Control-Allow-Methods", allowMethods)
+		}
+		if allowHeaders != "" {
+			header.Set("Access-Control-Allow-Headers", allowHeaders)
+		} else if reqHeaders := c.requestHeader("Access-Control-Request-Headers"); reqHeaders != "" {
+			header.Set("Access-Control-Allow-Headers", reqHeaders)
+		}
+		if maxAge != "" {
+			header.Set("Access-Control-Max-Age", maxAge)
+		}
+		c.AbortWithStatus(http.StatusNoContent)
+	}
+}
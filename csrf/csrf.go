@@ -0,0 +1,255 @@
+package csrf
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"html/template"
+	"jin"
+	"jin/sessions"
+	"net/http"
+	"strings"
+)
+
+// tokenLength 是 CSRF 密钥的字节数
+const tokenLength = 32
+
+// secretKey 是 Context.Keys 和会话中保存 CSRF 密钥的键
+const secretKey = "jin/csrf"
+
+// fieldNameKey 是 Context.Keys 中保存表单字段名的键，TemplateField 用它生成字段
+const fieldNameKey = "jin/csrf/field"
+
+// Storage 决定 CSRF 密钥保存在哪里
+type Storage uint8
+
+const (
+	// SessionStorage 把密钥保存在 sessions.Sessions 中间件创建的会话里
+	SessionStorage Storage = iota
+	// CookieStorage 把密钥保存在单独的 cookie 中（签名的 double-submit cookie），不需要会话，
+	// cookie 使用 Config.Key 做 HMAC 签名，防止兄弟子域名植入已知的密钥
+	CookieStorage
+)
+
+// Config 定义 CSRF 中间件的配置
+type Config struct {
+	// Storage 是密钥的保存方式
+	// 可选。默认值为 SessionStorage
+	Storage Storage
+
+	// FieldName 是表单中令牌的字段名
+	// 可选。默认值为 "_csrf"
+	FieldName string
+
+	// HeaderName 是携带令牌的请求头
+	// 可选。默认值为 "X-CSRF-Token"
+	HeaderName string
+
+	// CookieName 是 CookieStorage 模式下保存密钥的 cookie 名
+	// 可选。默认值为 "_csrf"
+	CookieName string
+
+	// Key 是 CookieStorage 模式下签名密钥 cookie 的 HMAC 密钥，至少 32 字节
+	// CookieStorage 模式下必填
+	Key []byte
+
+	// CookieOptions 是 CookieStorage 模式下密钥 cookie 的属性
+	// 可选。默认为 HttpOnly、SameSite=Lax 的会话 cookie
+	CookieOptions sessions.Options
+
+	// ErrorHandler 在令牌校验失败时调用
+	// 可选。默认返回 403
+	ErrorHandler jin.HandlerFunc
+}
+
+// New 返回一个使用默认配置的 CSRF 中间件，需要先注册 sessions.Sessions 中间件
+func New() jin.HandlerFunc {
+	return Middleware(Config{})
+}
+
+// Middleware 返回一个 CSRF 防护中间件
+// 对 GET、HEAD、OPTIONS 和 TRACE 以外的请求，它从请求头或表单字段读取令牌并与密钥比较，
+// 不匹配时返回 403。每次调用 Token 都会返回一个重新掩码的令牌，避免 BREACH 攻击
+func Middleware(conf Config) jin.HandlerFunc {
+	if conf.FieldName == "" {
+		conf.FieldName = "_csrf"
+	}
+	if conf.HeaderName == "" {
+		conf.HeaderName = "X-CSRF-Token"
+	}
+	if conf.CookieName == "" {
+		conf.CookieName = "_csrf"
+	}
+	if conf.Storage == CookieStorage && len(conf.Key) < 32 {
+		panic("csrf: Config.Key of at least 32 bytes is required for CookieStorage")
+	}
+	if conf.CookieOptions == (sessions.Options{}) {
+		conf.CookieOptions = sessions.Options{
+			Path:     "/",
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+		}
+	}
+	if conf.ErrorHandler == nil {
+		conf.ErrorHandler = func(c *jin.Context) {
+			c.AbortWithStatus(http.StatusForbidden)
+		}
+	}
+
+	return func(c *jin.Context) {
+		secret, err := conf.loadSecret(c)
+		if err != nil {
+			c.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+		c.Set(secretKey, secret)
+		c.Set(fieldNameKey, conf.FieldName)
+
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+			return
+		}
+
+		token := c.GetHeader(conf.HeaderName)
+		if token == "" {
+			token = c.PostForm(conf.FieldName)
+		}
+		if !validToken(token, secret) {
+			c.Abort()
+			conf.ErrorHandler(c)
+		}
+	}
+}
+
+// loadSecret 读取密钥，不存在时生成并保存
+func (conf *Config) loadSecret(c *jin.Context) ([]byte, error) {
+	if conf.Storage == CookieStorage {
+		if value, err := c.Cookie(conf.CookieName); err == nil {
+			if secret, ok := conf.verifyCookie(value); ok {
+				return secret, nil
+			}
+		}
+		secret, err := randomBytes(tokenLength)
+		if err != nil {
+			return nil, err
+		}
+		opts := conf.CookieOptions
+		c.SetCookieData(&http.Cookie{
+			Name:     conf.CookieName,
+			Value:    conf.signCookie(secret),
+			Path:     opts.Path,
+			Domain:   opts.Domain,
+			MaxAge:   opts.MaxAge,
+			Secure:   opts.Secure,
+			HttpOnly: opts.HttpOnly,
+			SameSite: opts.SameSite,
+		})
+		return secret, nil
+	}
+
+	session := sessions.Default(c)
+	if secret, ok := session.Get(secretKey).([]byte); ok && len(secret) == tokenLength {
+		return secret, nil
+	}
+	secret, err := randomBytes(tokenLength)
+	if err != nil {
+		return nil, err
+	}
+	session.Set(secretKey, secret)
+	return secret, session.Save()
+}
+
+// signCookie 返回 base64(密钥) "." base64(HMAC-SHA256(cookie 名|密钥))
+func (conf *Config) signCookie(secret []byte) string {
+	return base64.RawURLEncoding.EncodeToString(secret) + "." +
+		base64.RawURLEncoding.EncodeToString(conf.cookieMAC(secret))
+}
+
+// verifyCookie 校验签名并返回 cookie 中的密钥
+func (conf *Config) verifyCookie(value string) ([]byte, bool) {
+	i := strings.IndexByte(value, '.')
+	if i < 0 {
+		return nil, false
+	}
+	secret, err := base64.RawURLEncoding.DecodeString(value[:i])
+	if err != nil || len(secret) != tokenLength {
+		return nil, false
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(value[i+1:])
+	if err != nil || !hmac.Equal(mac, conf.cookieMAC(secret)) {
+		return nil, false
+	}
+	return secret, true
+}
+
+func (conf *Config) cookieMAC(secret []byte) []byte {
+	h := hmac.New(sha256.New, conf.Key)
+	h.Write([]byte(conf.CookieName))
+	h.Write([]byte{'|'})
+	h.Write(secret)
+	return h.Sum(nil)
+}
+
+// Token 返回当前请求的掩码令牌，用于表单字段或请求头
+func Token(c *jin.Context) string {
+	secret, ok := c.MustGet(secretKey).([]byte)
+	if !ok {
+		return ""
+	}
+	return mask(secret)
+}
+
+// TemplateField 返回包含令牌的隐藏表单字段，字段名为中间件配置的 Config.FieldName
+func TemplateField(c *jin.Context) template.HTML {
+	name := c.GetString(fieldNameKey)
+	if name == "" {
+		name = "_csrf"
+	}
+	return template.HTML(`<input type="hidden" name="` + template.HTMLEscapeString(name) + `" value="` + Token(c) + `">`)
+}
+
+// FuncMap 返回可以合并到 Engine.FuncMap 的模板函数：
+// {{ csrfToken .ctx }} 输出令牌，{{ csrfField .ctx }} 输出隐藏表单字段，.ctx 是渲染时传入的 *jin.Context
+func FuncMap() template.FuncMap {
+	return template.FuncMap{
+		"csrfToken": Token,
+		"csrfField": TemplateField,
+	}
+}
+
+// mask 用一次性随机数对密钥做异或，令牌为 随机数||密文 的 base64
+func mask(secret []byte) string {
+	pad, err := randomBytes(len(secret))
+	if err != nil {
+		panic(err)
+	}
+	token := make([]byte, 2*len(secret))
+	copy(token, pad)
+	for i := range secret {
+		token[len(secret)+i] = pad[i] ^ secret[i]
+	}
+	return base64.RawURLEncoding.EncodeToString(token)
+}
+
+func validToken(token string, secret []byte) bool {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(raw) != 2*len(secret) {
+		return false
+	}
+	pad, masked := raw[:len(secret)], raw[len(secret):]
+	unmasked := make([]byte, len(secret))
+	for i := range secret {
+		unmasked[i] = pad[i] ^ masked[i]
+	}
+	return subtle.ConstantTimeCompare(unmasked, secret) == 1
+}
+
+func randomBytes(n int) ([]byte, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
@@ -0,0 +1,87 @@
+package csrf
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestMaskRoundTrip(t *testing.T) {
+	secret, err := randomBytes(tokenLength)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, second := mask(secret), mask(secret)
+	if first == second {
+		t.Error("mask should return a different token each time")
+	}
+	for _, token := range []string{first, second} {
+		if !validToken(token, secret) {
+			t.Errorf("validToken(%q) = false, want true", token)
+		}
+	}
+}
+
+func TestValidTokenRejects(t *testing.T) {
+	secret := bytes.Repeat([]byte{1}, tokenLength)
+	other := bytes.Repeat([]byte{2}, tokenLength)
+	token := mask(secret)
+	raw, _ := base64.RawURLEncoding.DecodeString(token)
+	tampered := append([]byte(nil), raw...)
+	tampered[len(tampered)-1] ^= 0xff
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"empty", ""},
+		{"not base64", "!!!"},
+		{"too short", base64.RawURLEncoding.EncodeToString(raw[:len(raw)-1])},
+		{"too long", base64.RawURLEncoding.EncodeToString(append(raw, 0))},
+		{"unmasked secret", base64.RawURLEncoding.EncodeToString(secret)},
+		{"tampered", base64.RawURLEncoding.EncodeToString(tampered)},
+		{"other secret", mask(other)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if validToken(tt.token, secret) {
+				t.Errorf("validToken(%q) = true, want false", tt.token)
+			}
+		})
+	}
+}
+
+func TestSignedCookie(t *testing.T) {
+	conf := &Config{CookieName: "_csrf", Key: bytes.Repeat([]byte("k"), 32)}
+	secret := bytes.Repeat([]byte{7}, tokenLength)
+	value := conf.signCookie(secret)
+
+	got, ok := conf.verifyCookie(value)
+	if !ok || !bytes.Equal(got, secret) {
+		t.Fatalf("verifyCookie(signCookie(secret)) = %v, %v", got, ok)
+	}
+
+	parts := strings.SplitN(value, ".", 2)
+	otherKey := &Config{CookieName: "_csrf", Key: bytes.Repeat([]byte("x"), 32)}
+	otherName := &Config{CookieName: "other", Key: conf.Key}
+	tests := []struct {
+		name  string
+		conf  *Config
+		value string
+	}{
+		{"unsigned secret", conf, parts[0]},
+		{"planted secret", conf, base64.RawURLEncoding.EncodeToString(bytes.Repeat([]byte{9}, tokenLength)) + "." + parts[1]},
+		{"bad mac", conf, parts[0] + ".AAAA"},
+		{"short secret", conf, conf.signCookie(secret[:8])},
+		{"other key", otherKey, value},
+		{"other cookie name", otherName, value},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok := tt.conf.verifyCookie(tt.value); ok {
+				t.Errorf("verifyCookie(%q) = true, want false", tt.value)
+			}
+		})
+	}
+}
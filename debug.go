@@ -2,6 +2,7 @@ package jin
 
 import (
 	"fmt"
+	"log/slog"
 	"strings"
 )
 
@@ -14,14 +15,23 @@ func IsDebugging() bool {
 // DebugPrintRouterFunc 指出 debug 日志输出的格式
 var DebugPrintRouterFunc func(httpMethod, absolutePath, handlerName string, nuHandlers int)
 
-func debugPrintRoute(httpMethod, absolutePath string, handlers HandlerChain) {
+// debugPrintRoute 输出注册的路由，logger 非 nil 时以结构化日志写入 logger
+func debugPrintRoute(logger *slog.Logger, httpMethod, absolutePath string, handlers HandlerChain) {
 	if IsDebugging() {
 		nuHandlers := len(handlers)
 		handlerName := nameOfFunction(handlers.Last())
-		if DebugPrintRouterFunc == nil {
-			debugPrint("%-6s %-25s --> %s (%d handlers)\n", httpMethod, absolutePath, handlerName, nuHandlers)
-		} else {
+		switch {
+		case DebugPrintRouterFunc != nil:
 			DebugPrintRouterFunc(httpMethod, absolutePath, handlerName, nuHandlers)
+		case logger != nil:
+			logger.Debug("route registered",
+				slog.String("method", httpMethod),
+				slog.String("path", absolutePath),
+				slog.String("handler", handlerName),
+				slog.Int("handlers", nuHandlers),
+			)
+		default:
+			debugPrint("%-6s %-25s --> %s (%d handlers)\n", httpMethod, absolutePath, handlerName, nuHandlers)
 		}
 	}
 }
@@ -31,8 +41,20 @@ func debugPrint(format string, values ...interface{}) {
 		if !strings.HasSuffix(format, "\n") {
 			format += "\n"
 		}
-		fmt.Printf(format, values)
+		fmt.Fprintf(DefaultWriter, "[JIN-debug] "+format, values...)
+	}
+}
+
+// debugPrint 与包级别的 debugPrint 相同，但配置了 engine.Slog 时写入 engine.Slog
+func (engine *Engine) debugPrint(format string, values ...interface{}) {
+	if !IsDebugging() {
+		return
+	}
+	if engine.Slog != nil {
+		engine.Slog.Debug(strings.TrimSuffix(fmt.Sprintf(format, values...), "\n"))
+		return
 	}
+	debugPrint(format, values...)
 }
 
 func debugPrintError(err error) {
@@ -0,0 +1,225 @@
+package jin
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ETagConfig 定义 ETag 中间件的配置
+type ETagConfig struct {
+	// Weak 为 true 时生成弱 ETag (W/"...")
+	// 可选。默认生成强 ETag
+	Weak bool
+}
+
+// ETag 返回一个生成强 ETag 并处理条件请求的中间件
+func ETag() HandlerFunc {
+	return ETagWithConfig(ETagConfig{})
+}
+
+// ETagWithConfig 返回一个处理 If-None-Match 和 If-Modified-Since 条件请求的中间件
+// 它只作用于 GET 和 HEAD 请求的 200 响应：处理器自己设置了 ETag 或 Last-Modified 时，
+// 中间件只负责校验前置条件；否则缓冲响应体并根据内容计算 ETag。
+// 条件满足时返回 304 Not Modified 且不发送响应体
+func ETagWithConfig(conf ETagConfig) HandlerFunc {
+	return func(c *Context) {
+		if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+			c.Next()
+			return
+		}
+
+		ew := &etagWriter{
+			ResponseWriter: c.Writer,
+			req:            c.Request,
+			weak:           conf.Weak,
+		}
+		c.Writer = ew
+		defer func() {
+			ew.close()
+			c.Writer = ew.ResponseWriter
+		}()
+		c.Next()
+	}
+}
+
+type etagState uint8
+
+const (
+	etagUndecided etagState = iota
+	etagPassthrough
+	etagBuffering
+	etagNotModified
+)
+
+// etagWriter 在第一次写出时决定是透传、缓冲还是直接返回 304
+type etagWriter struct {
+	ResponseWriter
+	req   *http.Request
+	weak  bool
+	state etagState
+	buf   []byte
+}
+
+var _ ResponseWriter = &etagWriter{}
+
+func (w *etagWriter) decide(streaming bool) {
+	if w.state != etagUndecided {
+		return
+	}
+	header := w.ResponseWriter.Header()
+	switch {
+	case w.ResponseWriter.Status() != http.StatusOK:
+		w.state = etagPassthrough
+	case header.Get("ETag") != "" || header.Get("Last-Modified") != "":
+		if notModified(w.req, header) {
+			w.writeNotModified()
+		} else {
+			w.state = etagPassthrough
+		}
+	case streaming:
+		w.state = etagPassthrough
+	default:
+		w.state = etagBuffering
+	}
+}
+
+func (w *etagWriter) writeNotModified() {
+	w.state = etagNotModified
+	w.buf = nil
+	header := w.ResponseWriter.Header()
+	header.Del("Content-Type")
+	header.Del("Content-Length")
+	w.ResponseWriter.WriteHeader(http.StatusNotModified)
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *etagWriter) Write(data []byte) (int, error) {
+	w.decide(false)
+	switch w.state {
+	case etagBuffering:
+		w.buf = append(w.buf, data...)
+		return len(data), nil
+	case etagNotModified:
+		// 304 不能有响应体，丢弃写入
+		return len(data), nil
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *etagWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *etagWriter) WriteHeader(code int) {
+	if w.state == etagNotModified || w.state == etagBuffering {
+		return
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// WriteHeaderNow 在缓冲时推迟到 close 才写出响应头
+func (w *etagWriter) WriteHeaderNow() {
+	w.decide(false)
+	if w.state == etagPassthrough {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+}
+
+func (w *etagWriter) Written() bool {
+	return w.state == etagBuffering || w.ResponseWriter.Written()
+}
+
+// Flush 用于流式响应，此时不再计算 ETag
+func (w *etagWriter) Flush() {
+	if w.state == etagBuffering {
+		w.state = etagPassthrough
+		w.flushBuffer()
+	}
+	w.decide(true)
+	if w.state == etagPassthrough {
+		w.ResponseWriter.Flush()
+	}
+}
+
+func (w *etagWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.state = etagPassthrough
+	w.buf = nil
+	return w.ResponseWriter.Hijack()
+}
+
+func (w *etagWriter) flushBuffer() {
+	if len(w.buf) > 0 {
+		w.ResponseWriter.Write(w.buf)
+	} else {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+	w.buf = nil
+}
+
+// close 为缓冲的响应计算 ETag 并写出完整响应或 304
+func (w *etagWriter) close() {
+	if w.state != etagBuffering {
+		return
+	}
+	header := w.ResponseWriter.Header()
+	if header.Get("ETag") == "" {
+		header.Set("ETag", computeETag(w.buf, w.weak))
+	}
+	if notModified(w.req, header) {
+		w.writeNotModified()
+		return
+	}
+	w.flushBuffer()
+}
+
+func computeETag(body []byte, weak bool) string {
+	sum := sha1.Sum(body)
+	etag := `"` + hex.EncodeToString(sum[:]) + `"`
+	if weak {
+		return "W/" + etag
+	}
+	return etag
+}
+
+// notModified 根据 RFC 7232 判断条件 GET 是否可以返回 304
+// If-None-Match 存在时忽略 If-Modified-Since
+func notModified(req *http.Request, header http.Header) bool {
+	if inm := req.Header.Get("If-None-Match"); inm != "" {
+		etag := header.Get("ETag")
+		if etag == "" {
+			return false
+		}
+		for _, candidate := range strings.Split(inm, ",") {
+			candidate = strings.TrimSpace(candidate)
+			if candidate == "*" || weakETagMatch(candidate, etag) {
+				return true
+			}
+		}
+		return false
+	}
+
+	ims := req.Header.Get("If-Modified-Since")
+	lm := header.Get("Last-Modified")
+	if ims == "" || lm == "" {
+		return false
+	}
+	since, err := http.ParseTime(ims)
+	if err != nil {
+		return false
+	}
+	modified, err := http.ParseTime(lm)
+	if err != nil {
+		return false
+	}
+	return !modified.Truncate(time.Second).After(since)
+}
+
+// weakETagMatch 使用弱比较，GET 和 HEAD 的条件请求应该这样比较
+func weakETagMatch(a, b string) bool {
+	return strings.TrimPrefix(a, "W/") == strings.TrimPrefix(b, "W/")
+}
@@ -0,0 +1,219 @@
+package jin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// defaultHealthCheckTimeout 是没有指定超时的检查使用的超时时间
+const defaultHealthCheckTimeout = 5 * time.Second
+
+// errShuttingDown 是服务开始关闭后 readiness 检查返回的错误
+var errShuttingDown = errors.New("server is shutting down")
+
+// HealthCheck 检查一个依赖是否健康，返回 nil 表示健康，ctx 在超时后取消
+type HealthCheck func(ctx context.Context) error
+
+type namedCheck struct {
+	name    string
+	timeout time.Duration
+	check   HealthCheck
+}
+
+// Health 管理 /healthz、/readyz 和 /livez 使用的检查
+type Health struct {
+	mu           sync.RWMutex
+	readiness    []namedCheck
+	liveness     []namedCheck
+	shuttingDown int32
+}
+
+// HealthChecks 注册 /healthz、/readyz 和 /livez 三个路由，并返回用于添加检查的 *Health
+//   - /livez 只运行存活检查，失败通常意味着进程需要重启
+//   - /readyz 运行就绪检查，Shutdown 之后总是失败，让负载均衡器不再转发新请求，
+//     优雅关闭时应该使用 Drain 代替直接调用 srv.Shutdown
+//   - /healthz 运行所有检查
+//
+// 所有检查并发运行，全部通过时返回 200，否则返回 503，响应体是汇总的 JSON。
+// 多次调用返回同一个 *Health
+func (engine *Engine) HealthChecks() *Health {
+	if engine.health != nil {
+		return engine.health
+	}
+	h := &Health{}
+	engine.health = h
+	routes := []struct {
+		path    string
+		handler HandlerFunc
+	}{
+		{"/healthz", h.handler(true, true)},
+		{"/readyz", h.handler(true, false)},
+		{"/livez", h.handler(false, true)},
+	}
+	for _, route := range routes {
+		engine.GET(route.path, route.handler)
+		engine.HEAD(route.path, route.handler)
+	}
+	return h
+}
+
+// AddReadinessCheck 添加一个就绪检查，timeout 为 0 时使用 5 秒
+func (h *Health) AddReadinessCheck(name string, timeout time.Duration, check HealthCheck) *Health {
+	h.mu.Lock()
+	h.readiness = append(h.readiness, newNamedCheck(name, timeout, check))
+	h.mu.Unlock()
+	return h
+}
+
+// AddLivenessCheck 添加一个存活检查，timeout 为 0 时使用 5 秒
+func (h *Health) AddLivenessCheck(name string, timeout time.Duration, check HealthCheck) *Health {
+	h.mu.Lock()
+	h.liveness = append(h.liveness, newNamedCheck(name, timeout, check))
+	h.mu.Unlock()
+	return h
+}
+
+func newNamedCheck(name string, timeout time.Duration, check HealthCheck) namedCheck {
+	assert1(name != "", "health check name can not be empty")
+	assert1(check != nil, "health check can not be nil")
+	if timeout <= 0 {
+		timeout = defaultHealthCheckTimeout
+	}
+	return namedCheck{name: name, timeout: timeout, check: check}
+}
+
+// Shutdown 标记服务开始关闭，之后 /readyz 总是返回 503
+func (h *Health) Shutdown() {
+	atomic.StoreInt32(&h.shuttingDown, 1)
+}
+
+// ShuttingDown 返回是否已经调用过 Shutdown
+func (h *Health) ShuttingDown() bool {
+	return atomic.LoadInt32(&h.shuttingDown) == 1
+}
+
+// RegisterOnShutdown 让 srv.Shutdown 调用 h.Shutdown
+// 注意 srv.Shutdown 先关闭监听和空闲连接再调用这些钩子，此时负载均衡器已经无法访问 /readyz，
+// 它只能保证 Shutdown 期间仍在处理的请求看到失败的就绪状态。需要让探测发现实例下线时使用 Drain
+func (h *Health) RegisterOnShutdown(srv *http.Server) {
+	srv.RegisterOnShutdown(h.Shutdown)
+}
+
+// Drain 优雅关闭 srv：先调用 h.Shutdown 让 /readyz 返回 503，等待 delay 让负载均衡器的就绪探测
+// 发现并摘除实例，再调用 srv.Shutdown(ctx) 等待正在处理的请求完成。delay 应该大于探测间隔乘以失败阈值，
+// ctx 在等待期间取消时立即关闭
+//
+//	<-quit
+//	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+//	defer cancel()
+//	if err := health.Drain(ctx, srv, 10*time.Second); err != nil {
+//		log.Fatal(err)
+//	}
+func (h *Health) Drain(ctx context.Context, srv *http.Server, delay time.Duration) error {
+	h.Shutdown()
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+		}
+	}
+	return srv.Shutdown(ctx)
+}
+
+// HealthCheckResult 是单个检查的结果
+type HealthCheckResult struct {
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+// HealthReport 是健康检查路由返回的 JSON
+type HealthReport struct {
+	Status string                       `json:"status"`
+	Checks map[string]HealthCheckResult `json:"checks,omitempty"`
+}
+
+func (h *Health) handler(readiness, liveness bool) HandlerFunc {
+	return func(c *Context) {
+		h.mu.RLock()
+		var checks []namedCheck
+		if liveness {
+			checks = append(checks, h.liveness...)
+		}
+		if readiness {
+			checks = append(checks, h.readiness...)
+		}
+		h.mu.RUnlock()
+
+		report := runHealthChecks(c.Request.Context(), checks)
+		if readiness && h.ShuttingDown() {
+			report.Status = "fail"
+			report.Checks["shutdown"] = HealthCheckResult{Status: "fail", Error: errShuttingDown.Error(), Duration: "0s"}
+		}
+
+		code := http.StatusOK
+		if report.Status != "ok" {
+			code = http.StatusServiceUnavailable
+		}
+		c.Header("Cache-Control", "no-store")
+		c.JSON(code, report)
+	}
+}
+
+// runHealthChecks 并发运行所有检查，每个检查在自己的超时时间内完成
+func runHealthChecks(ctx context.Context, checks []namedCheck) HealthReport {
+	report := HealthReport{Status: "ok", Checks: make(map[string]HealthCheckResult, len(checks))}
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for _, nc := range checks {
+		wg.Add(1)
+		go func(nc namedCheck) {
+			defer wg.Done()
+			start := time.Now()
+			err := runHealthCheck(ctx, nc)
+			result := HealthCheckResult{Status: "ok", Duration: time.Since(start).String()}
+			if err != nil {
+				result.Status = "fail"
+				result.Error = err.Error()
+			}
+			mu.Lock()
+			report.Checks[nc.name] = result
+			if err != nil {
+				report.Status = "fail"
+			}
+			mu.Unlock()
+		}(nc)
+	}
+	wg.Wait()
+	return report
+}
+
+// runHealthCheck 在超时后立即返回，不等待没有响应 ctx 的检查
+func runHealthCheck(ctx context.Context, nc namedCheck) error {
+	ctx, cancel := context.WithTimeout(ctx, nc.timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				done <- errors.New("health check panicked")
+			}
+		}()
+		done <- nc.check(ctx)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -1,7 +0,0 @@
-package json
-
-import "encoding/json"
-
-var (
-	NewDecoder = json.NewDecoder
-)
@@ -0,0 +1,14 @@
+//go:build go_json
+
+package json
+
+import json "github.com/goccy/go-json"
+
+// 使用 -tags=go_json 编译时启用 goccy/go-json
+var (
+	Marshal       = json.Marshal
+	Unmarshal     = json.Unmarshal
+	MarshalIndent = json.MarshalIndent
+	NewDecoder    = json.NewDecoder
+	NewEncoder    = json.NewEncoder
+)
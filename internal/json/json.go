@@ -0,0 +1,16 @@
+//go:build !jsoniter && !go_json
+
+package json
+
+import "encoding/json"
+
+// 默认使用标准库 encoding/json
+// 使用 -tags=jsoniter 或 -tags=go_json 编译可以切换为更快的实现
+// 同时指定两个标签时使用 go_json
+var (
+	Marshal       = json.Marshal
+	Unmarshal     = json.Unmarshal
+	MarshalIndent = json.MarshalIndent
+	NewDecoder    = json.NewDecoder
+	NewEncoder    = json.NewEncoder
+)
@@ -0,0 +1,15 @@
+//go:build jsoniter && !go_json
+
+package json
+
+import jsoniter "github.com/json-iterator/go"
+
+// 使用 -tags=jsoniter 编译时启用 json-iterator，行为与标准库兼容
+var (
+	json          = jsoniter.ConfigCompatibleWithStandardLibrary
+	Marshal       = json.Marshal
+	Unmarshal     = json.Unmarshal
+	MarshalIndent = json.MarshalIndent
+	NewDecoder    = json.NewDecoder
+	NewEncoder    = json.NewEncoder
+)
@@ -1,6 +1,10 @@
 package jin
 
-import "sync"
+import (
+	"jin/binding"
+	"log/slog"
+	"sync"
+)
 
 var (
 	default404Body   = []byte("404 page not found")
@@ -24,6 +28,15 @@ type RouteInfo struct {
 	Path        string
 	Handler     string
 	HandlerFunc HandlerFunc
+	// Permissions 是注册路由时组上通过 RequireRoles、RequirePermissions、Authorize 或 UseAuthorization
+	// 记录的授权要求，如 "role:admin|editor"、"permission:posts:write"、"policy:owner"
+	// 为空表示路由没有通过这些方法声明保护
+	Permissions []string
+}
+
+// Protected 返回路由是否经过授权中间件保护
+func (info RouteInfo) Protected() bool {
+	return len(info.Permissions) > 0
 }
 
 type RoutesInfo []RouteInfo
@@ -46,6 +59,18 @@ type Engine struct {
 
 	MaxMultipartMemory int64
 
+	// MaxBodyBytes 限制请求体的最大字节数，超出时绑定返回 *binding.LimitError 并响应 413
+	// 0 表示不限制，单个路由可以通过 MaxBodyBytes 中间件覆盖
+	MaxBodyBytes int64
+
+	// Slog 非 nil 时，debug 日志、Logger 和 Recovery 中间件都以结构化日志写入它
+	// debug 日志使用 slog.LevelDebug 级别
+	Slog *slog.Logger
+
+	// Validator 是此 Engine 绑定时使用的校验器，为 nil 时使用全局的 binding.Validator
+	// 可以用 binding.NewValidator() 创建，让同一进程中的多个 Engine 拥有不同的规则
+	Validator binding.StructValidator
+
 	delims           render.Delims
 	secureJsonPrefix string
 	HTMLRender       render.HTMLRender
@@ -56,16 +81,85 @@ type Engine struct {
 	noMethod         HandlerChain
 	pool             sync.Pool
 	trees            methodTrees
+	health           *Health
+	routePermissions map[string][]string
 }
 
 var _ IRouter = &Engine{} // 确保 Engine 实现 IRouter 接口
 
+// NoRoute 添加 NoRoute 的处理器，默认返回 404
+func (engine *Engine) NoRoute(handlers ...HandlerFunc) {
+	engine.noRoute = handlers
+	engine.rebuild404Handlers()
+}
+
+// NoMethod 设置 engine.HandleMethodNotAllowed = true 时使用的处理器
+func (engine *Engine) NoMethod(handlers ...HandlerFunc) {
+	engine.noMethod = handlers
+	engine.rebuild405Handlers()
+}
+
+// Use 把全局中间件加到路由上，通过 Use() 加的中间件会包含在每个请求的处理器链中，
+// 包括 404、405 和静态文件。例如这里是 logger 或错误管理中间件的位置，
+// CORS 中间件也需要在这里注册，才能在没有 OPTIONS 处理器的路由上应答预检请求
+func (engine *Engine) Use(middleware ...HandlerFunc) IRoutes {
+	engine.RouterGroup.Use(middleware...)
+	engine.rebuild404Handlers()
+	engine.rebuild405Handlers()
+	return engine
+}
+
+func (engine *Engine) rebuild404Handlers() {
+	engine.allNoRoute = engine.combineHandlers(engine.noRoute)
+}
+
+func (engine *Engine) rebuild405Handlers() {
+	engine.allNoMethod = engine.combineHandlers(engine.noMethod)
+}
+
+// Routes 返回注册的路由，包括每个路由的授权要求
+func (engine *Engine) Routes() (routes RoutesInfo) {
+	for _, tree := range engine.trees {
+		routes = engine.iterate("", tree.method, routes, tree.root)
+	}
+	return routes
+}
+
+// setRoutePermissions 记录路由注册时的授权要求
+func (engine *Engine) setRoutePermissions(method, path string, requirements []string) {
+	if len(requirements) == 0 {
+		return
+	}
+	if engine.routePermissions == nil {
+		engine.routePermissions = make(map[string][]string)
+	}
+	engine.routePermissions[method+" "+path] = append([]string(nil), requirements...)
+}
+
+func (engine *Engine) iterate(path, method string, routes RoutesInfo, root *node) RoutesInfo {
+	path += root.path
+	if len(root.handlers) > 0 {
+		handlerFunc := root.handlers.Last()
+		routes = append(routes, RouteInfo{
+			Method:      method,
+			Path:        path,
+			Handler:     nameOfFunction(handlerFunc),
+			HandlerFunc: handlerFunc,
+			Permissions: engine.routePermissions[method+" "+path],
+		})
+	}
+	for _, child := range root.children {
+		routes = engine.iterate(path, method, routes, child)
+	}
+	return routes
+}
+
 func (engine *Engine) addRoute(method, path string, handlers HandlerChain) {
 	assert1(path[0] == '/', "path must begin with '/'")
 	assert1(method != "", "HTTP method can not be empty")
 	assert1(len(handlers) > 0, "there must be at least one handler")
 
-	debugPrintRoute(method, path, handlers)
+	debugPrintRoute(engine.Slog, method, path, handlers)
 	root := engine.trees.get(method)
 	if root == nil {
 		root = new(node)
@@ -0,0 +1,87 @@
+package jwt
+
+import (
+	"jin/internal/json"
+	"time"
+)
+
+// Claims 由声明类型实现，自定义类型嵌入 RegisteredClaims 即可获得标准声明的校验
+//
+//	type UserClaims struct {
+//		jwt.RegisteredClaims
+//		Roles []string `json:"roles"`
+//	}
+type Claims interface {
+	Registered() *RegisteredClaims
+}
+
+// RegisteredClaims 是 RFC 7519 中注册的标准声明
+type RegisteredClaims struct {
+	Issuer    string   `json:"iss,omitempty"`
+	Subject   string   `json:"sub,omitempty"`
+	Audience  Audience `json:"aud,omitempty"`
+	ExpiresAt int64    `json:"exp,omitempty"`
+	NotBefore int64    `json:"nbf,omitempty"`
+	IssuedAt  int64    `json:"iat,omitempty"`
+	ID        string   `json:"jti,omitempty"`
+}
+
+var _ Claims = &RegisteredClaims{}
+
+func (c *RegisteredClaims) Registered() *RegisteredClaims {
+	return c
+}
+
+// Audience 可以从字符串或字符串数组解码
+type Audience []string
+
+func (a *Audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = Audience{single}
+		return nil
+	}
+	var multi []string
+	if err := json.Unmarshal(data, &multi); err != nil {
+		return err
+	}
+	*a = multi
+	return nil
+}
+
+func (a Audience) MarshalJSON() ([]byte, error) {
+	if len(a) == 1 {
+		return json.Marshal(a[0])
+	}
+	return json.Marshal([]string(a))
+}
+
+// Contains 判断 aud 中是否包含 audience
+func (a Audience) Contains(audience string) bool {
+	for _, v := range a {
+		if v == audience {
+			return true
+		}
+	}
+	return false
+}
+
+// validate 校验时间相关的声明以及 iss 和 aud，requireExp 为 true 时拒绝没有 exp 的令牌
+func (c *RegisteredClaims) validate(now time.Time, leeway time.Duration, issuer, audience string, requireExp bool) error {
+	if c.ExpiresAt == 0 && requireExp {
+		return ErrMissingExpiration
+	}
+	if c.ExpiresAt != 0 && now.Add(-leeway).Unix() >= c.ExpiresAt {
+		return ErrTokenExpired
+	}
+	if c.NotBefore != 0 && now.Add(leeway).Unix() < c.NotBefore {
+		return ErrTokenNotValidYet
+	}
+	if issuer != "" && c.Issuer != issuer {
+		return ErrInvalidIssuer
+	}
+	if audience != "" && !c.Audience.Contains(audience) {
+		return ErrInvalidAudience
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package jwt
+
+import (
+	"jin"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ClaimsKey 是 Middleware 在 Context.Keys 中保存声明的键
+const ClaimsKey = "jin/jwt/claims"
+
+// Config 定义 JWT 中间件的配置
+type Config struct {
+	// Keys 提供验证签名的密钥，可以是 StaticKeySet 或 LoadJWKSFile 的结果
+	Keys KeySet
+
+	// Algorithms 是允许的签名算法
+	// 可选。默认值为 HS256、RS256 和 ES256
+	Algorithms []string
+
+	// Issuer 不为空时要求 iss 声明与之相同
+	// 可选。
+	Issuer string
+
+	// Audience 不为空时要求 aud 声明包含它
+	// 可选。
+	Audience string
+
+	// Leeway 是校验 exp 和 nbf 时允许的时钟偏差
+	// 可选。
+	Leeway time.Duration
+
+	// RequireExpiration 为 true 时拒绝没有 exp 声明的令牌，这样的令牌永不过期
+	// 可选。nil 表示 true，需要接受没有 exp 的令牌时设置为指向 false 的指针
+	RequireExpiration *bool
+
+	// TokenLookup 是读取令牌的位置，按顺序尝试，格式为 "header:<name>"、"cookie:<name>" 或 "query:<name>"
+	// 从请求头读取时会去掉 "Bearer " 前缀
+	// 可选。默认值为 []string{"header:Authorization"}
+	TokenLookup []string
+
+	// NewClaims 返回用于解码载荷的声明，使用自定义声明类型时设置
+	// 可选。默认返回 *RegisteredClaims
+	NewClaims func() Claims
+
+	// Realm 是 WWW-Authenticate 中的 realm
+	// 可选。默认值为 "Authorization Required"
+	Realm string
+}
+
+func (conf *Config) setDefaults() {
+	if conf.Keys == nil {
+		panic("jwt: Config.Keys is required")
+	}
+	if len(conf.Algorithms) == 0 {
+		conf.Algorithms = []string{HS256, RS256, ES256}
+	}
+	if len(conf.TokenLookup) == 0 {
+		conf.TokenLookup = []string{"header:Authorization"}
+	}
+	if conf.NewClaims == nil {
+		conf.NewClaims = func() Claims { return &RegisteredClaims{} }
+	}
+	if conf.Realm == "" {
+		conf.Realm = "Authorization Required"
+	}
+}
+
+// Middleware 返回一个校验 bearer 令牌的中间件，成功时把声明保存在 Context.Keys[ClaimsKey]，
+// 声明类型实现了 jin.Principal 时同时保存在 Context.Keys[jin.PrincipalKey]，供 jin.RequireRoles 等授权中间件使用
+// 失败时设置 WWW-Authenticate，并以 ErrorTypePublic 调用 AbortWithError(401)
+func Middleware(conf Config) jin.HandlerFunc {
+	conf.setDefaults()
+	return func(c *jin.Context) {
+		token := conf.extract(c)
+		if token == "" {
+			// RFC 6750：请求没有携带凭证时不返回错误码
+			c.Header("WWW-Authenticate", `Bearer realm="`+conf.Realm+`"`)
+			c.AbortWithError(http.StatusUnauthorized, ErrTokenMissing).SetType(jin.ErrorTypePublic)
+			return
+		}
+		claims, err := conf.Parse(token)
+		if err != nil {
+			c.Header("WWW-Authenticate", `Bearer realm="`+conf.Realm+`", error="invalid_token", error_description="`+err.Error()+`"`)
+			c.AbortWithError(http.StatusUnauthorized, err).SetType(jin.ErrorTypePublic)
+			return
+		}
+		c.Set(ClaimsKey, claims)
+		if p, ok := claims.(jin.Principal); ok {
+			c.Set(jin.PrincipalKey, p)
+		}
+	}
+}
+
+// Parse 校验令牌的签名和声明，返回 NewClaims 创建的声明
+func (conf Config) Parse(token string) (Claims, error) {
+	conf.setDefaults()
+	claims := conf.NewClaims()
+	if err := parse(token, claims, conf.Keys, conf.Algorithms); err != nil {
+		return nil, err
+	}
+	if err := claims.Registered().validate(time.Now(), conf.Leeway, conf.Issuer, conf.Audience, conf.requireExpiration()); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+func (conf *Config) requireExpiration() bool {
+	return conf.RequireExpiration == nil || *conf.RequireExpiration
+}
+
+func (conf *Config) extract(c *jin.Context) string {
+	for _, lookup := range conf.TokenLookup {
+		parts := strings.SplitN(lookup, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		name := strings.TrimSpace(parts[1])
+		var token string
+		switch strings.TrimSpace(parts[0]) {
+		case "header":
+			token = c.Request.Header.Get(name)
+			if len(token) > 7 && strings.EqualFold(token[:7], "Bearer ") {
+				token = token[7:]
+			} else if name == "Authorization" {
+				token = ""
+			}
+		case "cookie":
+			token, _ = c.Cookie(name)
+		case "query":
+			token = c.Query(name)
+		}
+		if token = strings.TrimSpace(token); token != "" {
+			return token
+		}
+	}
+	return ""
+}
+
+// GetClaims 返回 Middleware 保存的声明，使用自定义声明类型时需要类型断言
+func GetClaims(c *jin.Context) (Claims, bool) {
+	v, ok := c.Get(ClaimsKey)
+	if !ok {
+		return nil, false
+	}
+	claims, ok := v.(Claims)
+	return claims, ok
+}
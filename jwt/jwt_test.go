@@ -0,0 +1,266 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	testSecret = []byte("0123456789abcdef0123456789abcdef")
+	testRSA    *rsa.PrivateKey
+	testEC     *ecdsa.PrivateKey
+	testKeys   StaticKeySet
+)
+
+func init() {
+	var err error
+	if testRSA, err = rsa.GenerateKey(rand.Reader, 2048); err != nil {
+		panic(err)
+	}
+	if testEC, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader); err != nil {
+		panic(err)
+	}
+	testKeys = StaticKeySet{"hs": testSecret, "rs": &testRSA.PublicKey, "es": &testEC.PublicKey}
+}
+
+type userClaims struct {
+	RegisteredClaims
+	Roles []string `json:"roles"`
+}
+
+// rawToken 拼出一个任意头和载荷的令牌，用来构造非法令牌
+func rawToken(header, payload interface{}, sig []byte) string {
+	h, _ := json.Marshal(header)
+	p, _ := json.Marshal(payload)
+	return encodeSegment(h) + "." + encodeSegment(p) + "." + encodeSegment(sig)
+}
+
+func validClaims() *userClaims {
+	now := time.Now().Unix()
+	return &userClaims{
+		RegisteredClaims: RegisteredClaims{
+			Issuer:    "issuer",
+			Audience:  Audience{"api", "web"},
+			IssuedAt:  now,
+			ExpiresAt: now + 60,
+		},
+		Roles: []string{"admin"},
+	}
+}
+
+func TestSignAndParse(t *testing.T) {
+	tests := []struct {
+		alg string
+		key interface{}
+		kid string
+	}{
+		{HS256, testSecret, "hs"},
+		{RS256, testRSA, "rs"},
+		{ES256, testEC, "es"},
+	}
+	conf := Config{Keys: testKeys, Issuer: "issuer", Audience: "web", NewClaims: func() Claims { return &userClaims{} }}
+	for _, tt := range tests {
+		t.Run(tt.alg, func(t *testing.T) {
+			token, err := Sign(validClaims(), tt.alg, tt.key, tt.kid)
+			if err != nil {
+				t.Fatal(err)
+			}
+			claims, err := conf.Parse(token)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if roles := claims.(*userClaims).Roles; len(roles) != 1 || roles[0] != "admin" {
+				t.Errorf("roles = %v, want [admin]", roles)
+			}
+		})
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	pub, _ := x509.MarshalPKIXPublicKey(&testRSA.PublicKey)
+	pubPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pub})
+	confusion, _ := Sign(validClaims(), HS256, pubPEM, "rs")
+	confusionDER, _ := Sign(validClaims(), HS256, pub, "rs")
+	wrongSecret, _ := Sign(validClaims(), HS256, []byte("wrong"), "hs")
+	unknownKid, _ := Sign(validClaims(), HS256, testSecret, "missing")
+	noKid, _ := Sign(validClaims(), HS256, testSecret, "")
+	rsAsES, _ := Sign(validClaims(), RS256, testRSA, "es")
+	good, _ := Sign(validClaims(), HS256, testSecret, "hs")
+
+	tests := []struct {
+		name  string
+		token string
+		want  error
+	}{
+		{"alg none", rawToken(header{Alg: "none", Kid: "hs"}, validClaims(), nil), ErrAlgNotAllowed},
+		{"alg None", rawToken(header{Alg: "None", Kid: "hs"}, validClaims(), nil), ErrAlgNotAllowed},
+		{"hs256 with rsa public key pem", confusion, ErrInvalidKey},
+		{"hs256 with rsa public key der", confusionDER, ErrInvalidKey},
+		{"rs256 token with ec key", rsAsES, ErrInvalidKey},
+		{"wrong secret", wrongSecret, ErrSignatureInvalid},
+		{"unknown kid", unknownKid, ErrUnknownKey},
+		{"missing kid", noKid, ErrUnknownKey},
+		{"two segments", "a.b", ErrTokenMalformed},
+		{"bad header", "!!!" + good[strings.IndexByte(good, '.'):], ErrTokenMalformed},
+		{"truncated signature", good[:len(good)-4], ErrSignatureInvalid},
+	}
+	conf := Config{Keys: testKeys}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := conf.Parse(tt.token); err != tt.want {
+				t.Errorf("Parse() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestAlgorithmsAllowList(t *testing.T) {
+	token, _ := Sign(validClaims(), RS256, testRSA, "rs")
+	conf := Config{Keys: testKeys, Algorithms: []string{ES256}}
+	if _, err := conf.Parse(token); err != ErrAlgNotAllowed {
+		t.Errorf("Parse() error = %v, want %v", err, ErrAlgNotAllowed)
+	}
+}
+
+func TestRegisteredClaims(t *testing.T) {
+	now := time.Now().Unix()
+	tests := []struct {
+		name   string
+		claims RegisteredClaims
+		conf   Config
+		want   error
+	}{
+		{"valid", RegisteredClaims{ExpiresAt: now + 60}, Config{}, nil},
+		{"expired", RegisteredClaims{ExpiresAt: now - 10}, Config{}, ErrTokenExpired},
+		{"expired within leeway", RegisteredClaims{ExpiresAt: now - 10}, Config{Leeway: time.Minute}, nil},
+		{"expired beyond leeway", RegisteredClaims{ExpiresAt: now - 120}, Config{Leeway: time.Minute}, ErrTokenExpired},
+		{"not valid yet", RegisteredClaims{ExpiresAt: now + 600, NotBefore: now + 60}, Config{}, ErrTokenNotValidYet},
+		{"nbf within leeway", RegisteredClaims{ExpiresAt: now + 600, NotBefore: now + 30}, Config{Leeway: time.Minute}, nil},
+		{"missing exp", RegisteredClaims{}, Config{}, ErrMissingExpiration},
+		{"missing exp allowed", RegisteredClaims{}, Config{RequireExpiration: new(bool)}, nil},
+		{"issuer match", RegisteredClaims{ExpiresAt: now + 60, Issuer: "a"}, Config{Issuer: "a"}, nil},
+		{"issuer mismatch", RegisteredClaims{ExpiresAt: now + 60, Issuer: "b"}, Config{Issuer: "a"}, ErrInvalidIssuer},
+		{"audience string", RegisteredClaims{ExpiresAt: now + 60, Audience: Audience{"api"}}, Config{Audience: "api"}, nil},
+		{"audience array", RegisteredClaims{ExpiresAt: now + 60, Audience: Audience{"web", "api"}}, Config{Audience: "api"}, nil},
+		{"audience missing", RegisteredClaims{ExpiresAt: now + 60}, Config{Audience: "api"}, ErrInvalidAudience},
+		{"audience mismatch", RegisteredClaims{ExpiresAt: now + 60, Audience: Audience{"web", "admin"}}, Config{Audience: "api"}, ErrInvalidAudience},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := tt.claims
+			token, err := Sign(&claims, HS256, testSecret, "hs")
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.conf.Keys = testKeys
+			if _, err := tt.conf.Parse(token); err != tt.want {
+				t.Errorf("Parse() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestAudienceJSON(t *testing.T) {
+	tests := []struct {
+		json string
+		want Audience
+	}{
+		{`"api"`, Audience{"api"}},
+		{`["api","web"]`, Audience{"api", "web"}},
+	}
+	for _, tt := range tests {
+		var aud Audience
+		if err := json.Unmarshal([]byte(tt.json), &aud); err != nil {
+			t.Fatal(err)
+		}
+		if fmt.Sprint(aud) != fmt.Sprint(tt.want) {
+			t.Errorf("Unmarshal(%s) = %v, want %v", tt.json, aud, tt.want)
+		}
+		data, _ := json.Marshal(aud)
+		if string(data) != tt.json {
+			t.Errorf("Marshal(%v) = %s, want %s", aud, data, tt.json)
+		}
+	}
+}
+
+func TestIssuerRefresh(t *testing.T) {
+	iss := &Issuer{Alg: HS256, Key: testSecret, KeyID: "hs", Issuer: "issuer", TTL: time.Minute}
+	conf := Config{Keys: testKeys, Issuer: "issuer"}
+	now := time.Now().Unix()
+
+	expired, _ := Sign(&RegisteredClaims{Issuer: "issuer", IssuedAt: now - 120, ExpiresAt: now - 60}, HS256, testSecret, "hs")
+	tooOld, _ := Sign(&RegisteredClaims{Issuer: "issuer", IssuedAt: now - 7200, ExpiresAt: now - 3600}, HS256, testSecret, "hs")
+	forged, _ := Sign(&RegisteredClaims{Issuer: "issuer", IssuedAt: now, ExpiresAt: now - 1}, HS256, []byte("wrong"), "hs")
+	otherIssuer, _ := Sign(&RegisteredClaims{Issuer: "other", IssuedAt: now, ExpiresAt: now + 60}, HS256, testSecret, "hs")
+
+	tests := []struct {
+		name  string
+		token string
+		want  error
+	}{
+		{"expired within window", expired, nil},
+		{"too old", tooOld, ErrRefreshExpired},
+		{"forged", forged, ErrSignatureInvalid},
+		{"other issuer", otherIssuer, ErrInvalidIssuer},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := iss.Refresh(tt.token, conf, time.Hour)
+			if err != tt.want {
+				t.Fatalf("Refresh() error = %v, want %v", err, tt.want)
+			}
+			if err == nil {
+				if _, err := conf.Parse(token); err != nil {
+					t.Errorf("Parse(refreshed) error = %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestParseJWKS(t *testing.T) {
+	b64 := base64.RawURLEncoding.EncodeToString
+	ecX, ecY := testEC.PublicKey.X.FillBytes(make([]byte, 32)), testEC.PublicKey.Y.FillBytes(make([]byte, 32))
+	doc := fmt.Sprintf(`{"keys":[
+		{"kty":"RSA","kid":"rs","use":"sig","n":%q,"e":"AQAB"},
+		{"kty":"EC","kid":"es","crv":"P-256","x":%q,"y":%q},
+		{"kty":"oct","kid":"hs","k":%q},
+		{"kty":"RSA","kid":"enc","use":"enc","n":%q,"e":"AQAB"}
+	]}`, b64(testRSA.N.Bytes()), b64(ecX), b64(ecY), b64(testSecret), b64(testRSA.N.Bytes()))
+	keys, err := ParseJWKS([]byte(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := keys["enc"]; ok {
+		t.Error("encryption key should be ignored")
+	}
+	conf := Config{Keys: keys}
+	for kid, key := range map[string]interface{}{"rs": testRSA, "es": testEC, "hs": testSecret} {
+		alg := map[string]string{"rs": RS256, "es": ES256, "hs": HS256}[kid]
+		token, _ := Sign(validClaims(), alg, key, kid)
+		if _, err := conf.Parse(token); err != nil {
+			t.Errorf("%s: Parse() error = %v", kid, err)
+		}
+	}
+
+	for name, bad := range map[string]string{
+		"unsupported curve": `{"keys":[{"kty":"EC","crv":"P-384","x":"AA","y":"AA"}]}`,
+		"point off curve":   fmt.Sprintf(`{"keys":[{"kty":"EC","crv":"P-256","x":%q,"y":%q}]}`, b64(ecX), b64(ecX)),
+		"unknown kty":       `{"keys":[{"kty":"OKP"}]}`,
+		"empty modulus":     `{"keys":[{"kty":"RSA","n":"","e":"AQAB"}]}`,
+	} {
+		if _, err := ParseJWKS([]byte(bad)); err == nil {
+			t.Errorf("%s: ParseJWKS() error = nil", name)
+		}
+	}
+}
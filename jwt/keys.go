@@ -0,0 +1,124 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"jin/internal/json"
+	"math/big"
+	"os"
+)
+
+// KeySet 根据令牌头中的 kid 和 alg 返回验证签名的密钥
+type KeySet interface {
+	VerificationKey(kid, alg string) (interface{}, error)
+}
+
+// StaticKeySet 是固定的 kid 到密钥的映射，kid 为 "" 的密钥用于没有 kid 的令牌
+// HS256 使用 []byte，RS256 使用 *rsa.PublicKey，ES256 使用 *ecdsa.PublicKey
+type StaticKeySet map[string]interface{}
+
+var _ KeySet = StaticKeySet{}
+
+func (s StaticKeySet) VerificationKey(kid, alg string) (interface{}, error) {
+	key, ok := s[kid]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	return key, nil
+}
+
+// jwk 是 RFC 7517 中的 JSON Web Key，只保留验证签名需要的字段
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	K   string `json:"k"`
+}
+
+// LoadJWKSFile 从本地 JWKS 文件读取密钥，支持 RSA、P-256 EC 和 oct 类型，use 不是 sig 的密钥会被忽略
+func LoadJWKSFile(path string) (StaticKeySet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseJWKS(data)
+}
+
+// ParseJWKS 解析 JWKS 文档
+func ParseJWKS(data []byte) (StaticKeySet, error) {
+	var doc struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	keys := make(StaticKeySet, len(doc.Keys))
+	for _, k := range doc.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			return nil, err
+		}
+		keys[k.Kid] = key
+	}
+	return keys, nil
+}
+
+func (k jwk) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, ErrInvalidKey
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, ErrInvalidKey
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		curve := elliptic.P256()
+		if !curve.IsOnCurve(x, y) {
+			return nil, ErrInvalidKey
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "oct":
+		secret, err := base64.RawURLEncoding.DecodeString(k.K)
+		if err != nil || len(secret) == 0 {
+			return nil, ErrInvalidKey
+		}
+		return secret, nil
+	}
+	return nil, ErrInvalidKey
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return nil, ErrInvalidKey
+	}
+	return new(big.Int).SetBytes(b), nil
+}
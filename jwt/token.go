@@ -0,0 +1,251 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"jin/internal/json"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// 支持的签名算法
+const (
+	HS256 = "HS256"
+	RS256 = "RS256"
+	ES256 = "ES256"
+)
+
+var (
+	ErrTokenMissing      = errors.New("jwt: token is missing")
+	ErrTokenMalformed    = errors.New("jwt: token is malformed")
+	ErrTokenExpired      = errors.New("jwt: token is expired")
+	ErrMissingExpiration = errors.New("jwt: token has no expiration")
+	ErrTokenNotValidYet  = errors.New("jwt: token is not valid yet")
+	ErrSignatureInvalid  = errors.New("jwt: signature is invalid")
+	ErrAlgNotAllowed     = errors.New("jwt: signing algorithm is not allowed")
+	ErrUnknownKey        = errors.New("jwt: no key found for token")
+	ErrInvalidKey        = errors.New("jwt: key is invalid for the signing algorithm")
+	ErrInvalidIssuer     = errors.New("jwt: token has invalid issuer")
+	ErrInvalidAudience   = errors.New("jwt: token has invalid audience")
+	ErrRefreshExpired    = errors.New("jwt: token is too old to be refreshed")
+)
+
+type header struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ,omitempty"`
+	Kid string `json:"kid,omitempty"`
+}
+
+// Sign 使用 alg 和 key 为 claims 签名，kid 不为空时写入令牌头
+// HS256 使用 []byte，RS256 使用 *rsa.PrivateKey，ES256 使用 *ecdsa.PrivateKey
+func Sign(claims Claims, alg string, key interface{}, kid string) (string, error) {
+	h, err := json.Marshal(header{Alg: alg, Typ: "JWT", Kid: kid})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	signingInput := encodeSegment(h) + "." + encodeSegment(payload)
+	sig, err := sign(alg, key, signingInput)
+	if err != nil {
+		return "", err
+	}
+	return signingInput + "." + encodeSegment(sig), nil
+}
+
+// parse 校验签名并把载荷解码到 claims，不校验时间等声明
+func parse(token string, claims Claims, keys KeySet, algorithms []string) error {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return ErrTokenMalformed
+	}
+	hb, err := decodeSegment(parts[0])
+	if err != nil {
+		return ErrTokenMalformed
+	}
+	var h header
+	if err := json.Unmarshal(hb, &h); err != nil {
+		return ErrTokenMalformed
+	}
+	if !allowed(h.Alg, algorithms) {
+		return ErrAlgNotAllowed
+	}
+	sig, err := decodeSegment(parts[2])
+	if err != nil {
+		return ErrTokenMalformed
+	}
+	key, err := keys.VerificationKey(h.Kid, h.Alg)
+	if err != nil {
+		return err
+	}
+	if err := verify(h.Alg, key, parts[0]+"."+parts[1], sig); err != nil {
+		return err
+	}
+	payload, err := decodeSegment(parts[1])
+	if err != nil {
+		return ErrTokenMalformed
+	}
+	if err := json.Unmarshal(payload, claims); err != nil {
+		return ErrTokenMalformed
+	}
+	return nil
+}
+
+func allowed(alg string, algorithms []string) bool {
+	for _, a := range algorithms {
+		if a == alg {
+			return true
+		}
+	}
+	return false
+}
+
+func sign(alg string, key interface{}, signingInput string) ([]byte, error) {
+	digest := sha256.Sum256([]byte(signingInput))
+	switch alg {
+	case HS256:
+		secret, ok := key.([]byte)
+		if !ok || len(secret) == 0 {
+			return nil, ErrInvalidKey
+		}
+		mac := hmac.New(sha256.New, secret)
+		mac.Write([]byte(signingInput))
+		return mac.Sum(nil), nil
+	case RS256:
+		priv, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return nil, ErrInvalidKey
+		}
+		return rsa.SignPKCS1v15(rand.Reader, priv, crypto.SHA256, digest[:])
+	case ES256:
+		priv, ok := key.(*ecdsa.PrivateKey)
+		if !ok || priv.Curve.Params().BitSize != 256 {
+			return nil, ErrInvalidKey
+		}
+		r, s, err := ecdsa.Sign(rand.Reader, priv, digest[:])
+		if err != nil {
+			return nil, err
+		}
+		// JWS 要求 r 和 s 各占固定的 32 字节
+		sig := make([]byte, 64)
+		r.FillBytes(sig[:32])
+		s.FillBytes(sig[32:])
+		return sig, nil
+	}
+	return nil, ErrAlgNotAllowed
+}
+
+// verify 校验签名，密钥类型必须与算法匹配，以防止用公钥作为 HMAC 密钥的算法混淆攻击
+func verify(alg string, key interface{}, signingInput string, sig []byte) error {
+	digest := sha256.Sum256([]byte(signingInput))
+	switch alg {
+	case HS256:
+		secret, ok := key.([]byte)
+		if !ok || len(secret) == 0 {
+			return ErrInvalidKey
+		}
+		mac := hmac.New(sha256.New, secret)
+		mac.Write([]byte(signingInput))
+		if !hmac.Equal(sig, mac.Sum(nil)) {
+			return ErrSignatureInvalid
+		}
+		return nil
+	case RS256:
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return ErrInvalidKey
+		}
+		if rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], sig) != nil {
+			return ErrSignatureInvalid
+		}
+		return nil
+	case ES256:
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok || pub.Curve.Params().BitSize != 256 {
+			return ErrInvalidKey
+		}
+		if len(sig) != 64 {
+			return ErrSignatureInvalid
+		}
+		r := new(big.Int).SetBytes(sig[:32])
+		s := new(big.Int).SetBytes(sig[32:])
+		if !ecdsa.Verify(pub, digest[:], r, s) {
+			return ErrSignatureInvalid
+		}
+		return nil
+	}
+	return ErrAlgNotAllowed
+}
+
+func encodeSegment(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeSegment(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(s)
+}
+
+// Issuer 签发和刷新令牌
+type Issuer struct {
+	// Alg 是签名算法
+	Alg string
+	// Key 是签名的私钥，HS256 时是共享密钥
+	Key interface{}
+	// KeyID 写入令牌头的 kid
+	// 可选。
+	KeyID string
+	// Issuer 写入 iss 声明
+	// 可选。
+	Issuer string
+	// Audience 写入 aud 声明
+	// 可选。
+	Audience []string
+	// TTL 是令牌的有效期，为 0 时不设置 exp，这样的令牌默认会被 Config.RequireExpiration 拒绝
+	TTL time.Duration
+}
+
+// Issue 填充 iss、aud、iat、nbf、exp 后签发令牌，claims 中已经设置的 iss 和 aud 不会被覆盖
+func (i *Issuer) Issue(claims Claims) (string, error) {
+	rc := claims.Registered()
+	now := time.Now()
+	if rc.Issuer == "" {
+		rc.Issuer = i.Issuer
+	}
+	if len(rc.Audience) == 0 && len(i.Audience) > 0 {
+		rc.Audience = append(Audience(nil), i.Audience...)
+	}
+	rc.IssuedAt = now.Unix()
+	rc.NotBefore = now.Unix()
+	if i.TTL > 0 {
+		rc.ExpiresAt = now.Add(i.TTL).Unix()
+	}
+	return Sign(claims, i.Alg, i.Key, i.KeyID)
+}
+
+// Refresh 校验令牌签名和除 exp 之外的声明，令牌签发不超过 maxAge 时使用相同的声明签发新令牌
+// 过期的令牌也可以刷新，maxAge 限制了刷新窗口
+func (i *Issuer) Refresh(token string, conf Config, maxAge time.Duration) (string, error) {
+	conf.setDefaults()
+	claims := conf.NewClaims()
+	if err := parse(token, claims, conf.Keys, conf.Algorithms); err != nil {
+		return "", err
+	}
+	rc := claims.Registered()
+	now := time.Now()
+	if err := rc.validate(now, conf.Leeway, conf.Issuer, conf.Audience, conf.requireExpiration()); err != nil && err != ErrTokenExpired {
+		return "", err
+	}
+	if rc.IssuedAt == 0 || now.Sub(time.Unix(rc.IssuedAt, 0)) > maxAge {
+		return "", ErrRefreshExpired
+	}
+	return i.Issue(claims)
+}
@@ -0,0 +1,297 @@
+package jin
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/mattn/go-isatty"
+)
+
+type consoleColorModeValue int
+
+const (
+	autoColor consoleColorModeValue = iota
+	disableColor
+	forceColor
+)
+
+const (
+	green   = "\033[97;42m"
+	white   = "\033[90;47m"
+	yellow  = "\033[90;43m"
+	red     = "\033[97;41m"
+	blue    = "\033[97;44m"
+	magenta = "\033[97;45m"
+	cyan    = "\033[97;46m"
+	reset   = "\033[0m"
+)
+
+var consoleColorMode = autoColor
+
+// LoggerConfig 定义 Logger 中间件的配置
+type LoggerConfig struct {
+	// 可选。默认值为 jin.defaultLogFormatter
+	Formatter LogFormatter
+
+	// Output 是日志写入的地方
+	// 可选。默认值为 jin.DefaultWriter
+	Output io.Writer
+
+	// SkipPaths 是不需要写日志的 url 路径
+	// 可选。
+	SkipPaths []string
+
+	// Slog 非 nil 时以结构化日志写入，Formatter 和 Output 将被忽略
+	// 可选。未设置 Formatter 和 Output 时默认使用 Engine.Slog
+	Slog *slog.Logger
+}
+
+// LogFormatter 是传递给 LoggerWithFormatter 的格式化函数
+type LogFormatter func(params LogFormatterParams) string
+
+// LogFormatterParams 是日志格式化时可用的参数
+type LogFormatterParams struct {
+	Request *http.Request
+
+	// TimeStamp 是服务器返回响应后的时间
+	TimeStamp time.Time
+	// StatusCode 是 HTTP 响应状态码
+	StatusCode int
+	// Latency 是服务器处理某个请求花费的时间
+	Latency time.Duration
+	// ClientIP 等于 Context 的 ClientIP 方法
+	ClientIP string
+	// Method 是请求的 HTTP 方法
+	Method string
+	// Path 是客户端请求的路径
+	Path string
+	// ErrorMessage 是处理请求时发生的私有错误
+	ErrorMessage string
+	// isTerm 表示输出是否为终端
+	isTerm bool
+	// BodySize 是响应体的大小
+	BodySize int
+	// Keys 是请求上下文中设置的键
+	Keys map[string]interface{}
+	// RequestID 是 RequestID 中间件设置的请求 ID
+	RequestID string
+}
+
+// StatusCodeColor 是用于在终端里输出状态码的 ANSI 颜色
+func (p *LogFormatterParams) StatusCodeColor() string {
+	code := p.StatusCode
+
+	switch {
+	case code >= http.StatusOK && code < http.StatusMultipleChoices:
+		return green
+	case code >= http.StatusMultipleChoices && code < http.StatusBadRequest:
+		return white
+	case code >= http.StatusBadRequest && code < http.StatusInternalServerError:
+		return yellow
+	default:
+		return red
+	}
+}
+
+// MethodColor 是用于在终端里输出 HTTP 方法的 ANSI 颜色
+func (p *LogFormatterParams) MethodColor() string {
+	method := p.Method
+
+	switch method {
+	case http.MethodGet:
+		return blue
+	case http.MethodPost:
+		return cyan
+	case http.MethodPut:
+		return yellow
+	case http.MethodDelete:
+		return red
+	case http.MethodPatch:
+		return green
+	case http.MethodHead:
+		return magenta
+	case http.MethodOptions:
+		return white
+	default:
+		return reset
+	}
+}
+
+// ResetColor 重置所有 ANSI 颜色
+func (p *LogFormatterParams) ResetColor() string {
+	return reset
+}
+
+// IsOutputColor 表示是否可以输出带颜色的日志
+func (p *LogFormatterParams) IsOutputColor() bool {
+	return consoleColorMode == forceColor || (consoleColorMode == autoColor && p.isTerm)
+}
+
+// defaultLogFormatter 是 Logger 中间件默认使用的日志格式
+var defaultLogFormatter = func(param LogFormatterParams) string {
+	var statusColor, methodColor, resetColor string
+	if param.IsOutputColor() {
+		statusColor = param.StatusCodeColor()
+		methodColor = param.MethodColor()
+		resetColor = param.ResetColor()
+	}
+
+	if param.Latency > time.Minute {
+		param.Latency = param.Latency.Truncate(time.Second)
+	}
+	requestID := ""
+	if param.RequestID != "" {
+		requestID = " | " + param.RequestID
+	}
+	return fmt.Sprintf("[JIN] %v |%s %3d %s| %13v | %15s%s |%s %-7s %s %#v\n%s",
+		param.TimeStamp.Format("2006/01/02 - 15:04:05"),
+		statusColor, param.StatusCode, resetColor,
+		param.Latency,
+		param.ClientIP,
+		requestID,
+		methodColor, param.Method, resetColor,
+		param.Path,
+		param.ErrorMessage,
+	)
+}
+
+// DisableConsoleColor 禁用控制台的颜色输出
+func DisableConsoleColor() {
+	consoleColorMode = disableColor
+}
+
+// ForceConsoleColor 强制控制台输出颜色
+func ForceConsoleColor() {
+	consoleColorMode = forceColor
+}
+
+// Logger 实例化一个 Logger 中间件，它会把日志写到 jin.DefaultWriter
+// 默认情况下 jin.DefaultWriter = os.Stdout
+func Logger() HandlerFunc {
+	return LoggerWithConfig(LoggerConfig{})
+}
+
+// LoggerWithFormatter 实例化一个使用指定格式化函数的 Logger 中间件
+func LoggerWithFormatter(f LogFormatter) HandlerFunc {
+	return LoggerWithConfig(LoggerConfig{
+		Formatter: f,
+	})
+}
+
+// LoggerWithWriter 实例化一个写到指定 writer 的 Logger 中间件
+// notlogged 中的路径不会写日志
+func LoggerWithWriter(out io.Writer, notlogged ...string) HandlerFunc {
+	return LoggerWithConfig(LoggerConfig{
+		Output:    out,
+		SkipPaths: notlogged,
+	})
+}
+
+// LoggerWithConfig 实例化一个使用指定配置的 Logger 中间件
+func LoggerWithConfig(conf LoggerConfig) HandlerFunc {
+	formatter := conf.Formatter
+	if formatter == nil {
+		formatter = defaultLogFormatter
+	}
+
+	out := conf.Output
+	if out == nil {
+		out = DefaultWriter
+	}
+	inheritSlog := conf.Output == nil && conf.Formatter == nil
+
+	notlogged := conf.SkipPaths
+
+	isTerm := true
+
+	if w, ok := out.(*os.File); !ok || os.Getenv("TERM") == "dumb" ||
+		(!isatty.IsTerminal(w.Fd()) && !isatty.IsCygwinTerminal(w.Fd())) {
+		isTerm = false
+	}
+
+	var skip map[string]struct{}
+
+	if length := len(notlogged); length > 0 {
+		skip = make(map[string]struct{}, length)
+
+		for _, path := range notlogged {
+			skip[path] = struct{}{}
+		}
+	}
+
+	return func(c *Context) {
+		// 开始计时
+		start := time.Now()
+		path := c.Request.URL.Path
+		raw := c.Request.URL.RawQuery
+
+		// 处理请求
+		c.Next()
+
+		// 只为没有跳过的路径写日志
+		if _, ok := skip[path]; ok {
+			return
+		}
+
+		param := LogFormatterParams{
+			Request: c.Request,
+			isTerm:  isTerm,
+			Keys:    c.Keys,
+		}
+
+		// 停止计时
+		param.TimeStamp = time.Now()
+		param.Latency = param.TimeStamp.Sub(start)
+
+		param.ClientIP = c.ClientIP()
+		param.RequestID = c.requestID()
+		param.Method = c.Request.Method
+		param.StatusCode = c.Writer.Status()
+		param.ErrorMessage = c.Errors.ByType(ErrorTypePrivate).String()
+
+		param.BodySize = c.Writer.Size()
+
+		if raw != "" {
+			path = path + "?" + raw
+		}
+
+		param.Path = path
+
+		logger := conf.Slog
+		if logger == nil && inheritSlog {
+			logger = c.engine.Slog
+		}
+		if logger != nil {
+			logRequest(c, logger, param)
+			return
+		}
+
+		fmt.Fprint(out, formatter(param))
+	}
+}
+
+// logRequest 把一次请求以结构化日志写入 logger，4xx 使用 Warn 级别，5xx 使用 Error 级别
+func logRequest(c *Context, logger *slog.Logger, param LogFormatterParams) {
+	level := slog.LevelInfo
+	switch {
+	case param.StatusCode >= http.StatusInternalServerError:
+		level = slog.LevelError
+	case param.StatusCode >= http.StatusBadRequest:
+		level = slog.LevelWarn
+	}
+	attrs := append(c.slogAttrs(),
+		slog.String("method", param.Method),
+		slog.String("path", param.Path),
+		slog.Int("status", param.StatusCode),
+		slog.Duration("latency", param.Latency),
+		slog.Int("body_size", param.BodySize),
+	)
+	if param.ErrorMessage != "" {
+		attrs = append(attrs, slog.String("error", param.ErrorMessage))
+	}
+	logger.LogAttrs(c.Request.Context(), level, "request", attrs...)
+}
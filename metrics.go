@@ -0,0 +1,248 @@
+package jin
+
+import (
+	"bytes"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MetricsConfig 定义 Metrics 的配置
+type MetricsConfig struct {
+	// Namespace 是指标名的前缀，如 "api" 得到 api_http_requests_total
+	// 可选。
+	Namespace string
+
+	// DurationBuckets 是请求耗时直方图的桶上界，单位为秒
+	// 可选。默认与 Prometheus 的 DefBuckets 相同
+	DurationBuckets []float64
+
+	// SizeBuckets 是响应大小直方图的桶上界，单位为字节
+	// 可选。默认值为 100B 到 10MB 的 10 倍递增
+	SizeBuckets []float64
+}
+
+// Metrics 收集请求数、耗时、响应大小和正在处理的请求数，并以 Prometheus 文本格式输出
+// 路由标签使用 Context.FullPath() 而不是原始路径，避免路径参数导致标签基数膨胀
+//
+//	m := jin.NewMetrics(jin.MetricsConfig{})
+//	router.Use(m.Middleware())
+//	router.GET("/metrics", m.Handler())
+type Metrics struct {
+	mu       sync.Mutex
+	prefix   string
+	durBkts  []float64
+	sizeBkts []float64
+	requests map[requestLabels]*requestMetrics
+	inFlight map[routeLabels]int64
+}
+
+type routeLabels struct {
+	method string
+	route  string
+}
+
+type requestLabels struct {
+	routeLabels
+	status int
+}
+
+type requestMetrics struct {
+	duration histogram
+	size     histogram
+}
+
+// histogram 保存每个桶的计数（不累加），输出时再累加
+type histogram struct {
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+func (h *histogram) observe(buckets []float64, v float64) {
+	if h.counts == nil {
+		h.counts = make([]uint64, len(buckets))
+	}
+	if i := sort.SearchFloat64s(buckets, v); i < len(buckets) {
+		h.counts[i]++
+	}
+	h.sum += v
+	h.count++
+}
+
+// NewMetrics 创建一个指标收集器
+func NewMetrics(conf MetricsConfig) *Metrics {
+	if conf.DurationBuckets == nil {
+		conf.DurationBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+	}
+	if conf.SizeBuckets == nil {
+		conf.SizeBuckets = []float64{100, 1000, 10000, 100000, 1000000, 10000000}
+	}
+	assert1(sort.Float64sAreSorted(conf.DurationBuckets), "duration buckets must be sorted")
+	assert1(sort.Float64sAreSorted(conf.SizeBuckets), "size buckets must be sorted")
+	prefix := ""
+	if conf.Namespace != "" {
+		prefix = conf.Namespace + "_"
+	}
+	return &Metrics{
+		prefix:   prefix,
+		durBkts:  conf.DurationBuckets,
+		sizeBkts: conf.SizeBuckets,
+		requests: make(map[requestLabels]*requestMetrics),
+		inFlight: make(map[routeLabels]int64),
+	}
+}
+
+// Middleware 返回记录请求指标的中间件，应该尽量靠前注册
+func (m *Metrics) Middleware() HandlerFunc {
+	return func(c *Context) {
+		start := time.Now()
+		rl := routeLabels{method: metricsMethod(c.Request.Method), route: c.FullPath()}
+		m.addInFlight(rl, 1)
+		defer m.addInFlight(rl, -1)
+
+		c.Next()
+
+		size := c.Writer.Size()
+		if size < 0 {
+			size = 0
+		}
+		m.observe(requestLabels{rl, c.Writer.Status()}, time.Since(start), size)
+	}
+}
+
+// metricsMethod 把非标准的请求方法归为 "OTHER"，客户端可以发送任意方法，直接作为标签会让标签基数无限增长
+func metricsMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	}
+	return "OTHER"
+}
+
+func (m *Metrics) addInFlight(rl routeLabels, delta int64) {
+	m.mu.Lock()
+	m.inFlight[rl] += delta
+	m.mu.Unlock()
+}
+
+func (m *Metrics) observe(labels requestLabels, latency time.Duration, size int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	rm, ok := m.requests[labels]
+	if !ok {
+		rm = &requestMetrics{}
+		m.requests[labels] = rm
+	}
+	rm.duration.observe(m.durBkts, latency.Seconds())
+	rm.size.observe(m.sizeBkts, float64(size))
+}
+
+// Handler 返回以 Prometheus 文本格式 (0.0.4) 输出指标的处理器
+func (m *Metrics) Handler() HandlerFunc {
+	return func(c *Context) {
+		c.Data(http.StatusOK, "text/plain; version=0.0.4; charset=utf-8", m.expose())
+	}
+}
+
+func (m *Metrics) expose() []byte {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	keys := make([]requestLabels, 0, len(m.requests))
+	for k := range m.requests {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.route != b.route {
+			return a.route < b.route
+		}
+		if a.method != b.method {
+			return a.method < b.method
+		}
+		return a.status < b.status
+	})
+
+	var buf bytes.Buffer
+	name := m.prefix + "http_requests_total"
+	writeMetricHeader(&buf, name, "counter", "Total number of HTTP requests.")
+	for _, k := range keys {
+		writeSample(&buf, name, k.labels(), "", float64(m.requests[k].duration.count))
+	}
+
+	name = m.prefix + "http_request_duration_seconds"
+	writeMetricHeader(&buf, name, "histogram", "HTTP request latency in seconds.")
+	for _, k := range keys {
+		writeHistogram(&buf, name, k.labels(), m.durBkts, &m.requests[k].duration)
+	}
+
+	name = m.prefix + "http_response_size_bytes"
+	writeMetricHeader(&buf, name, "histogram", "HTTP response size in bytes.")
+	for _, k := range keys {
+		writeHistogram(&buf, name, k.labels(), m.sizeBkts, &m.requests[k].size)
+	}
+
+	flights := make([]routeLabels, 0, len(m.inFlight))
+	for k := range m.inFlight {
+		flights = append(flights, k)
+	}
+	sort.Slice(flights, func(i, j int) bool {
+		if flights[i].route != flights[j].route {
+			return flights[i].route < flights[j].route
+		}
+		return flights[i].method < flights[j].method
+	})
+	name = m.prefix + "http_requests_in_flight"
+	writeMetricHeader(&buf, name, "gauge", "Number of HTTP requests currently being served.")
+	for _, k := range flights {
+		writeSample(&buf, name, k.labels(), "", float64(m.inFlight[k]))
+	}
+	return buf.Bytes()
+}
+
+func (l routeLabels) labels() string {
+	return `method="` + escapeLabel(l.method) + `",route="` + escapeLabel(l.route) + `"`
+}
+
+func (l requestLabels) labels() string {
+	return l.routeLabels.labels() + `,status="` + strconv.Itoa(l.status) + `"`
+}
+
+func writeMetricHeader(buf *bytes.Buffer, name, typ, help string) {
+	buf.WriteString("# HELP " + name + " " + help + "\n")
+	buf.WriteString("# TYPE " + name + " " + typ + "\n")
+}
+
+func writeSample(buf *bytes.Buffer, name, labels, suffix string, v float64) {
+	buf.WriteString(name + suffix + "{" + labels + "} ")
+	buf.WriteString(formatFloat(v))
+	buf.WriteByte('\n')
+}
+
+func writeHistogram(buf *bytes.Buffer, name, labels string, buckets []float64, h *histogram) {
+	var cumulative uint64
+	for i, le := range buckets {
+		if h.counts != nil {
+			cumulative += h.counts[i]
+		}
+		writeSample(buf, name, labels+`,le="`+formatFloat(le)+`"`, "_bucket", float64(cumulative))
+	}
+	writeSample(buf, name, labels+`,le="+Inf"`, "_bucket", float64(h.count))
+	writeSample(buf, name, labels, "_sum", h.sum)
+	writeSample(buf, name, labels, "_count", float64(h.count))
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
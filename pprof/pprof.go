@@ -0,0 +1,49 @@
+// Package pprof 在 jin 的路由上注册 net/http/pprof 和 expvar 的处理器
+// net/http/pprof 和 expvar 在导入时会向 http.DefaultServeMux 注册处理器，
+// 所以它们放在单独的包里，只有导入这个包的程序才会暴露这些路由
+package pprof
+
+import (
+	"expvar"
+	"jin"
+	"net/http/pprof"
+)
+
+// DefaultPrefix 是 Register 默认的路由前缀
+const DefaultPrefix = "/debug"
+
+// Register 在 prefix 下注册 net/http/pprof 和 expvar 的处理器，只在 debug 模式下生效，返回是否注册
+// prefix 为空时使用 "/debug"，middleware 会加在这些路由之前，可以用来加上认证，例如：
+//
+//	pprof.Register(router, "", jin.BasicAuth(jin.Accounts{"admin": "secret"}))
+//
+// 注册的路由：
+//
+//	GET      {prefix}/pprof/            性能分析索引
+//	GET      {prefix}/pprof/cmdline
+//	GET      {prefix}/pprof/profile     CPU 分析，?seconds=N
+//	GET|POST {prefix}/pprof/symbol
+//	GET      {prefix}/pprof/trace       执行追踪，?seconds=N
+//	GET      {prefix}/pprof/{allocs,block,goroutine,heap,mutex,threadcreate}
+//	GET      {prefix}/vars              expvar
+func Register(router jin.IRouter, prefix string, middleware ...jin.HandlerFunc) bool {
+	if !jin.IsDebugging() {
+		return false
+	}
+	if prefix == "" {
+		prefix = DefaultPrefix
+	}
+	group := router.Group(prefix, middleware...)
+
+	group.GET("/pprof/", jin.WrapF(pprof.Index))
+	group.GET("/pprof/cmdline", jin.WrapF(pprof.Cmdline))
+	group.GET("/pprof/profile", jin.WrapF(pprof.Profile))
+	group.GET("/pprof/symbol", jin.WrapF(pprof.Symbol))
+	group.POST("/pprof/symbol", jin.WrapF(pprof.Symbol))
+	group.GET("/pprof/trace", jin.WrapF(pprof.Trace))
+	for _, name := range []string{"allocs", "block", "goroutine", "heap", "mutex", "threadcreate"} {
+		group.GET("/pprof/"+name, jin.WrapH(pprof.Handler(name)))
+	}
+	group.GET("/vars", jin.WrapH(expvar.Handler()))
+	return true
+}
@@ -0,0 +1,248 @@
+package jin
+
+import (
+	"hash/fnv"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimitStore 保存每个键的限流状态，可以替换为外部存储实现
+type RateLimitStore interface {
+	// Take 为 key 消耗一次配额，返回本次是否允许、剩余配额和配额重置的时间
+	Take(key string, limit RateLimit, now time.Time) RateLimitResult
+}
+
+// RateLimitAlgorithm 是限流算法
+type RateLimitAlgorithm uint8
+
+const (
+	// TokenBucket 令牌桶，允许不超过 Limit 的突发请求，令牌按 Limit/Period 的速率补充
+	TokenBucket RateLimitAlgorithm = iota
+	// SlidingWindow 近似滑动窗口：按上一个固定窗口的计数乘以它与当前滑动窗口重叠的比例，
+	// 再加上当前窗口的计数来估算最近 Period 内的请求数。假设上一个窗口的请求均匀分布，
+	// 因此实际通过的请求数可能略微偏离 Limit
+	SlidingWindow
+)
+
+// RateLimit 描述一条限流规则：每 Period 最多 Limit 个请求
+type RateLimit struct {
+	Limit     int
+	Period    time.Duration
+	Algorithm RateLimitAlgorithm
+}
+
+// RateLimitResult 是 RateLimitStore.Take 的结果
+type RateLimitResult struct {
+	Allowed   bool
+	Remaining int
+	// Reset 是配额完全恢复的时间
+	Reset time.Time
+	// RetryAfter 是被拒绝时需要等待的时间
+	RetryAfter time.Duration
+}
+
+// RateLimitConfig 定义 RateLimit 中间件的配置
+type RateLimitConfig struct {
+	RateLimit
+
+	// Name 是限流器的名字，会作为前缀加到键上，多个限流器共享同一个 Store 时用来隔离各自的状态
+	// 可选。默认由 Limit、Period 和 Algorithm 生成，规则相同的限流器共享 Store 时会共享配额，
+	// 需要独立配额时请设置不同的 Name
+	Name string
+
+	// KeyFunc 返回限流的键
+	// 可选。默认值为 RateLimitByIP
+	KeyFunc func(*Context) string
+
+	// Store 保存限流状态
+	// 可选。默认值为 NewMemoryRateLimitStore(Period * 2)，每个中间件独享
+	Store RateLimitStore
+
+	// Handler 在请求被限流时调用，此时限流相关的响应头已经写好
+	// 可选。默认返回 429
+	Handler HandlerFunc
+}
+
+// RateLimitByIP 以客户端 IP 作为限流的键
+func RateLimitByIP(c *Context) string {
+	return c.ClientIP()
+}
+
+// RateLimitByUser 以 c.Principal() 返回的认证主体作为限流的键，BasicAuth 和 jwt 等认证中间件都适用，
+// 没有认证主体时退回到客户端 IP
+func RateLimitByUser(c *Context) string {
+	if p, ok := c.Principal(); ok && p.Name() != "" {
+		return "user:" + p.Name()
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// RateLimiter 返回一个每 period 最多 limit 个请求、以客户端 IP 为键的令牌桶限流中间件
+// 在 RouterGroup 上 Use 即可为一组路由声明限流
+func RateLimiter(limit int, period time.Duration) HandlerFunc {
+	return RateLimiterWithConfig(RateLimitConfig{
+		RateLimit: RateLimit{Limit: limit, Period: period},
+	})
+}
+
+// RateLimiterWithConfig 返回一个限流中间件
+// 它总是设置 X-RateLimit-Limit、X-RateLimit-Remaining 和 X-RateLimit-Reset，
+// 被限流时返回 429 并设置 Retry-After
+func RateLimiterWithConfig(conf RateLimitConfig) HandlerFunc {
+	assert1(conf.Limit > 0, "rate limit must be greater than 0")
+	assert1(conf.Period > 0, "rate limit period must be greater than 0")
+	if conf.KeyFunc == nil {
+		conf.KeyFunc = RateLimitByIP
+	}
+	if conf.Store == nil {
+		conf.Store = NewMemoryRateLimitStore(2 * conf.Period)
+	}
+	if conf.Handler == nil {
+		conf.Handler = func(c *Context) {
+			c.AbortWithStatus(http.StatusTooManyRequests)
+		}
+	}
+	if conf.Name == "" {
+		conf.Name = strconv.Itoa(conf.Limit) + "/" + conf.Period.String() + "/" + strconv.Itoa(int(conf.Algorithm))
+	}
+	prefix := conf.Name + "|"
+	limit := strconv.Itoa(conf.Limit)
+
+	return func(c *Context) {
+		res := conf.Store.Take(prefix+conf.KeyFunc(c), conf.RateLimit, time.Now())
+
+		c.Header("X-RateLimit-Limit", limit)
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		c.Header("X-RateLimit-Reset", strconv.FormatInt(res.Reset.Unix(), 10))
+		if !res.Allowed {
+			retryAfter := int64(math.Ceil(res.RetryAfter.Seconds()))
+			if retryAfter < 1 {
+				retryAfter = 1
+			}
+			c.Header("Retry-After", strconv.FormatInt(retryAfter, 10))
+			c.Abort()
+			conf.Handler(c)
+		}
+	}
+}
+
+const rateLimitShards = 32
+
+// MemoryRateLimitStore 是进程内的分片 map 存储，超过 ttl 未访问的键会被清除
+type MemoryRateLimitStore struct {
+	shards [rateLimitShards]rateLimitShard
+	ttl    time.Duration
+}
+
+type rateLimitShard struct {
+	mu        sync.Mutex
+	entries   map[string]*rateLimitEntry
+	lastSweep time.Time
+}
+
+type rateLimitEntry struct {
+	// 令牌桶使用
+	tokens float64
+	// 滑动窗口使用：当前窗口开始时间、当前和上一窗口的计数
+	windowStart time.Time
+	count       int
+	prevCount   int
+
+	updated time.Time
+}
+
+var _ RateLimitStore = &MemoryRateLimitStore{}
+
+// NewMemoryRateLimitStore 返回一个进程内的限流存储
+func NewMemoryRateLimitStore(ttl time.Duration) *MemoryRateLimitStore {
+	s := &MemoryRateLimitStore{ttl: ttl}
+	for i := range s.shards {
+		s.shards[i].entries = make(map[string]*rateLimitEntry)
+	}
+	return s
+}
+
+func (s *MemoryRateLimitStore) shard(key string) *rateLimitShard {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return &s.shards[h.Sum32()%rateLimitShards]
+}
+
+func (s *MemoryRateLimitStore) Take(key string, limit RateLimit, now time.Time) RateLimitResult {
+	shard := s.shard(key)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	// 顺带清除过期的键，每个分片每 ttl 最多清除一次
+	if now.Sub(shard.lastSweep) > s.ttl {
+		for k, e := range shard.entries {
+			if now.Sub(e.updated) > s.ttl {
+				delete(shard.entries, k)
+			}
+		}
+		shard.lastSweep = now
+	}
+
+	e, ok := shard.entries[key]
+	if !ok {
+		e = &rateLimitEntry{tokens: float64(limit.Limit), windowStart: now}
+		shard.entries[key] = e
+	}
+	defer func() { e.updated = now }()
+
+	if limit.Algorithm == SlidingWindow {
+		return e.takeWindow(limit, now)
+	}
+	return e.takeToken(limit, now)
+}
+
+func (e *rateLimitEntry) takeToken(limit RateLimit, now time.Time) RateLimitResult {
+	rate := float64(limit.Limit) / float64(limit.Period)
+	if !e.updated.IsZero() {
+		e.tokens = math.Min(float64(limit.Limit), e.tokens+float64(now.Sub(e.updated))*rate)
+	}
+
+	res := RateLimitResult{}
+	if e.tokens >= 1 {
+		e.tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = time.Duration((1 - e.tokens) / rate)
+	}
+	res.Remaining = int(e.tokens)
+	res.Reset = now.Add(time.Duration((float64(limit.Limit) - e.tokens) / rate))
+	return res
+}
+
+func (e *rateLimitEntry) takeWindow(limit RateLimit, now time.Time) RateLimitResult {
+	elapsed := now.Sub(e.windowStart)
+	if elapsed >= limit.Period {
+		windows := elapsed / limit.Period
+		if windows == 1 {
+			e.prevCount = e.count
+		} else {
+			e.prevCount = 0
+		}
+		e.count = 0
+		e.windowStart = e.windowStart.Add(windows * limit.Period)
+		elapsed = now.Sub(e.windowStart)
+	}
+
+	// 按上一窗口在滑动窗口中剩余的比例估算请求数
+	weight := float64(limit.Period-elapsed) / float64(limit.Period)
+	estimated := float64(e.prevCount)*weight + float64(e.count)
+
+	res := RateLimitResult{Reset: e.windowStart.Add(limit.Period)}
+	if estimated+1 <= float64(limit.Limit) {
+		e.count++
+		estimated++
+		res.Allowed = true
+	} else {
+		res.RetryAfter = res.Reset.Sub(now)
+	}
+	res.Remaining = int(math.Max(0, float64(limit.Limit)-estimated))
+	return res
+}
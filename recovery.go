@@ -0,0 +1,203 @@
+package jin
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"log/slog"
+	"net/http"
+	"net/http/httputil"
+	"os"
+	"runtime"
+	"strings"
+	"syscall"
+	"time"
+)
+
+var (
+	dunno     = []byte("???")
+	centerDot = []byte("·")
+	dot       = []byte(".")
+	slash     = []byte("/")
+)
+
+// RecoveryFunc 定义 CustomRecovery 使用的处理函数
+type RecoveryFunc func(c *Context, err interface{})
+
+// Recovery 返回一个中间件，它会从任何 panic 中恢复，并在可能时返回 500
+func Recovery() HandlerFunc {
+	return RecoveryWithWriter(DefaultErrorWriter)
+}
+
+// CustomRecovery 返回一个中间件，它会从任何 panic 中恢复并调用 handle
+func CustomRecovery(handle RecoveryFunc) HandlerFunc {
+	return RecoveryWithWriter(DefaultErrorWriter, handle)
+}
+
+// RecoveryWithWriter 返回一个中间件，它会把 panic 写到指定的 writer，并在可能时返回 500
+func RecoveryWithWriter(out io.Writer, recovery ...RecoveryFunc) HandlerFunc {
+	if len(recovery) > 0 {
+		return CustomRecoveryWithWriter(out, recovery[0])
+	}
+	return CustomRecoveryWithWriter(out, defaultHandleRecovery)
+}
+
+// CustomRecoveryWithWriter 返回一个中间件，它会把 panic 写到指定的 writer 并调用 handle
+func CustomRecoveryWithWriter(out io.Writer, handle RecoveryFunc) HandlerFunc {
+	var logger *log.Logger
+	if out != nil {
+		logger = log.New(out, "\n\n\x1b[31m", log.LstdFlags)
+	}
+	return func(c *Context) {
+		defer func() {
+			if err := recover(); err != nil {
+				// 检查连接是否已断开，这种情况不需要栈信息，也不能再写响应
+				brokenPipe := isBrokenPipe(err)
+				if c.engine.Slog != nil {
+					logPanic(c, err, brokenPipe)
+				} else if logger != nil {
+					stack := stack(3)
+					headersToStr := dumpRequestHeaders(c.Request)
+					requestID := ""
+					if id := c.requestID(); id != "" {
+						requestID = " request_id=" + id
+					}
+					switch {
+					case brokenPipe:
+						logger.Printf("%s%s\n%s%s", err, requestID, headersToStr, reset)
+					case IsDebugging():
+						logger.Printf("[Recovery] %s panic recovered%s:\n%s\n%s\n%s%s",
+							timeFormat(time.Now()), requestID, headersToStr, err, stack, reset)
+					default:
+						logger.Printf("[Recovery] %s panic recovered%s:\n%s\n%s%s",
+							timeFormat(time.Now()), requestID, err, stack, reset)
+					}
+				}
+				if brokenPipe {
+					// 连接已断开，无法再写入状态码
+					if e, ok := err.(error); ok {
+						c.Error(e)
+					}
+					c.Abort()
+				} else {
+					handle(c, err)
+				}
+			}
+		}()
+		c.Next()
+	}
+}
+
+// logPanic 把 panic 以结构化日志写入 Engine.Slog
+func logPanic(c *Context, err interface{}, brokenPipe bool) {
+	attrs := []slog.Attr{slog.Any("error", err)}
+	if !brokenPipe {
+		attrs = append(attrs, slog.String("stack", string(stack(4))))
+	}
+	if headers := dumpRequestHeaders(c.Request); headers != "" {
+		attrs = append(attrs, slog.String("headers", headers))
+	}
+	c.Logger().LogAttrs(c.Request.Context(), slog.LevelError, "panic recovered", attrs...)
+}
+
+func defaultHandleRecovery(c *Context, err interface{}) {
+	if c.Writer.Written() {
+		c.Abort()
+		return
+	}
+	c.AbortWithStatus(http.StatusInternalServerError)
+}
+
+// isBrokenPipe 判断 panic 是否由 broken pipe 或 connection reset 引起
+func isBrokenPipe(err interface{}) bool {
+	e, ok := err.(error)
+	if !ok {
+		return false
+	}
+	if errors.Is(e, syscall.EPIPE) || errors.Is(e, syscall.ECONNRESET) {
+		return true
+	}
+	var se *os.SyscallError
+	if errors.As(e, &se) {
+		msg := strings.ToLower(se.Error())
+		return strings.Contains(msg, "broken pipe") || strings.Contains(msg, "connection reset by peer")
+	}
+	return false
+}
+
+// dumpRequestHeaders 在 debug 模式下导出请求头，Authorization 会被隐藏
+func dumpRequestHeaders(req *http.Request) string {
+	if !IsDebugging() || req == nil {
+		return ""
+	}
+	httpRequest, _ := httputil.DumpRequest(req, false)
+	headers := strings.Split(string(httpRequest), "\r\n")
+	for idx, header := range headers {
+		current := strings.Split(header, ":")
+		if strings.EqualFold(current[0], "Authorization") {
+			headers[idx] = current[0] + ": *"
+		}
+	}
+	return strings.Join(headers, "\r\n")
+}
+
+// stack 返回一个格式化好的调用栈，跳过 skip 层
+func stack(skip int) []byte {
+	buf := new(bytes.Buffer)
+	// 循环时打开文件并读取，这里记录已经加载的文件
+	var lines [][]byte
+	var lastFile string
+	for i := skip; ; i++ {
+		pc, file, line, ok := runtime.Caller(i)
+		if !ok {
+			break
+		}
+		// 至少打印这些，如果找不到源码就不显示
+		fmt.Fprintf(buf, "%s:%d (0x%x)\n", file, line, pc)
+		if file != lastFile {
+			data, err := ioutil.ReadFile(file)
+			if err != nil {
+				continue
+			}
+			lines = bytes.Split(data, []byte{'\n'})
+			lastFile = file
+		}
+		fmt.Fprintf(buf, "\t%s: %s\n", function(pc), source(lines, line))
+	}
+	return buf.Bytes()
+}
+
+// source 返回第 n 行去掉空白后的内容
+func source(lines [][]byte, n int) []byte {
+	n-- // 栈里的行号从 1 开始，数组从 0 开始
+	if n < 0 || n >= len(lines) {
+		return dunno
+	}
+	return bytes.TrimSpace(lines[n])
+}
+
+// function 返回 pc 所在函数的名字
+func function(pc uintptr) []byte {
+	fn := runtime.FuncForPC(pc)
+	if fn == nil {
+		return dunno
+	}
+	name := []byte(fn.Name())
+	// 名字包含包路径，如 runtime/debug.*T·ptrmethod，只保留 *T·ptrmethod
+	if lastSlash := bytes.LastIndex(name, slash); lastSlash >= 0 {
+		name = name[lastSlash+1:]
+	}
+	if period := bytes.Index(name, dot); period >= 0 {
+		name = name[period+1:]
+	}
+	name = bytes.Replace(name, centerDot, dot, -1)
+	return name
+}
+
+// timeFormat 返回自定义的时间格式
+func timeFormat(t time.Time) string {
+	return t.Format("2006/01/02 - 15:04:05")
+}
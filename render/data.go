@@ -0,0 +1,21 @@
+package render
+
+import "net/http"
+
+// Data 包含 Content-Type 和字节数据
+type Data struct {
+	ContentType string
+	Data        []byte
+}
+
+// Render (Data) 写入数据及其 Content-Type
+func (r Data) Render(w http.ResponseWriter) (err error) {
+	r.WriteContentType(w)
+	_, err = w.Write(r.Data)
+	return
+}
+
+// WriteContentType (Data) 写入自定义的 Content-Type
+func (r Data) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, []string{r.ContentType})
+}
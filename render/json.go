@@ -0,0 +1,55 @@
+package render
+
+import (
+	"jin/internal/json"
+	"net/http"
+)
+
+// JSON 包含给定的接口对象
+type JSON struct {
+	Data interface{}
+}
+
+// IndentedJSON 包含给定的接口对象，输出带缩进的 JSON
+type IndentedJSON struct {
+	Data interface{}
+}
+
+var jsonContentType = []string{"application/json; charset=utf-8"}
+
+// Render (JSON) 写入数据及其 Content-Type
+func (r JSON) Render(w http.ResponseWriter) error {
+	return WriteJSON(w, r.Data)
+}
+
+// WriteContentType (JSON) 写入 JSON 的 Content-Type
+func (r JSON) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, jsonContentType)
+}
+
+// WriteJSON 序列化给定的接口对象并连同 Content-Type 写入
+func WriteJSON(w http.ResponseWriter, obj interface{}) error {
+	writeContentType(w, jsonContentType)
+	jsonBytes, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(jsonBytes)
+	return err
+}
+
+// Render (IndentedJSON) 序列化给定的接口对象并连同 Content-Type 写入
+func (r IndentedJSON) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+	jsonBytes, err := json.MarshalIndent(r.Data, "", "    ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(jsonBytes)
+	return err
+}
+
+// WriteContentType (IndentedJSON) 写入 JSON 的 Content-Type
+func (r IndentedJSON) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, jsonContentType)
+}
@@ -0,0 +1,25 @@
+package render
+
+import "net/http"
+
+// Render 需要被 JSON、XML、HTML 等渲染器实现
+type Render interface {
+	// Render 写入数据及其 Content-Type
+	Render(http.ResponseWriter) error
+	// WriteContentType 只写入 Content-Type
+	WriteContentType(w http.ResponseWriter)
+}
+
+var (
+	_ Render = JSON{}
+	_ Render = IndentedJSON{}
+	_ Render = String{}
+	_ Render = Data{}
+)
+
+func writeContentType(w http.ResponseWriter, value []string) {
+	header := w.Header()
+	if val := header["Content-Type"]; len(val) == 0 {
+		header["Content-Type"] = value
+	}
+}
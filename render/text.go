@@ -0,0 +1,36 @@
+package render
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// String 包含给定的格式和参数
+type String struct {
+	Format string
+	Data   []interface{}
+}
+
+var plainContentType = []string{"text/plain; charset=utf-8"}
+
+// Render (String) 写入数据及其 Content-Type
+func (r String) Render(w http.ResponseWriter) error {
+	return WriteString(w, r.Format, r.Data)
+}
+
+// WriteContentType (String) 写入纯文本的 Content-Type
+func (r String) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, plainContentType)
+}
+
+// WriteString 根据格式写入数据，没有参数时原样写入
+func WriteString(w http.ResponseWriter, format string, data []interface{}) (err error) {
+	writeContentType(w, plainContentType)
+	if len(data) > 0 {
+		_, err = fmt.Fprintf(w, format, data...)
+		return
+	}
+	_, err = io.WriteString(w, format)
+	return
+}
@@ -0,0 +1,154 @@
+package jin
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"net/http"
+	"time"
+)
+
+// RequestIDKey 是 RequestID 中间件在 Context.Keys 中保存请求 ID 的键
+const RequestIDKey = "jin/requestid"
+
+// HeaderXRequestID 是默认用来传递请求 ID 的请求头和响应头
+const HeaderXRequestID = "X-Request-ID"
+
+// maxRequestIDLength 是接受客户端传入请求 ID 的最大长度，超过时重新生成
+const maxRequestIDLength = 128
+
+type requestIDContextKey struct{}
+
+// RequestIDConfig 定义 RequestID 中间件的配置
+type RequestIDConfig struct {
+	// Generator 生成新的请求 ID
+	// 可选。默认值为 NewUUID，也可以使用 NewULID
+	Generator func() string
+
+	// Header 是读取和回写请求 ID 的头
+	// 可选。默认值为 X-Request-ID
+	Header string
+
+	// IgnoreIncoming 为 true 时总是生成新的 ID，不信任客户端传入的值
+	// 可选。
+	IgnoreIncoming bool
+}
+
+// RequestID 返回一个使用默认配置的请求 ID 中间件
+func RequestID() HandlerFunc {
+	return RequestIDWithConfig(RequestIDConfig{})
+}
+
+// RequestIDWithConfig 返回一个请求 ID 中间件
+// 它读取或生成请求 ID，保存到 Context.Keys[RequestIDKey] 和请求的 context.Context 中，
+// 并在响应头中回写。Logger 和 Recovery 会自动带上它
+func RequestIDWithConfig(conf RequestIDConfig) HandlerFunc {
+	if conf.Generator == nil {
+		conf.Generator = NewUUID
+	}
+	if conf.Header == "" {
+		conf.Header = HeaderXRequestID
+	}
+
+	return func(c *Context) {
+		id := ""
+		if !conf.IgnoreIncoming {
+			id = c.requestHeader(conf.Header)
+		}
+		if !validRequestID(id) {
+			id = conf.Generator()
+		}
+
+		c.Set(RequestIDKey, id)
+		c.Request = c.Request.WithContext(ContextWithRequestID(c.Request.Context(), id))
+		c.Header(conf.Header, id)
+	}
+}
+
+// ContextWithRequestID 返回一个携带请求 ID 的 context.Context
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey{}, id)
+}
+
+// RequestIDFromContext 返回 RequestID 中间件保存在 context.Context 中的请求 ID
+// 发起下游 HTTP 请求时可以用它转发请求 ID
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDContextKey{}).(string)
+	return id
+}
+
+// RequestIDTransport 是一个 http.RoundTripper，它把请求 context 中的请求 ID 写入 X-Request-ID
+type RequestIDTransport struct {
+	// Base 为 nil 时使用 http.DefaultTransport
+	Base http.RoundTripper
+}
+
+func (t *RequestIDTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if id := RequestIDFromContext(req.Context()); id != "" && req.Header.Get(HeaderXRequestID) == "" {
+		req = req.Clone(req.Context())
+		req.Header.Set(HeaderXRequestID, id)
+	}
+	return base.RoundTrip(req)
+}
+
+// validRequestID 只接受长度合适的可打印 ASCII，避免客户端注入日志
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// NewUUID 生成一个随机的 UUIDv4
+func NewUUID() string {
+	var u [16]byte
+	if _, err := rand.Read(u[:]); err != nil {
+		panic(err)
+	}
+	u[6] = (u[6] & 0x0f) | 0x40 // 版本 4
+	u[8] = (u[8] & 0x3f) | 0x80 // RFC 4122 变体
+
+	var buf [36]byte
+	hex.Encode(buf[0:8], u[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], u[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], u[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], u[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], u[10:])
+	return string(buf[:])
+}
+
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// NewULID 生成一个 ULID，它按时间排序，适合作为数据库键
+func NewULID() string {
+	var u [16]byte
+	binary.BigEndian.PutUint64(u[:8], uint64(time.Now().UnixMilli())<<16)
+	if _, err := rand.Read(u[6:]); err != nil {
+		panic(err)
+	}
+
+	// 128 位按 5 位一组编码为 26 个字符，首字符只有 3 位
+	var buf [26]byte
+	hi := binary.BigEndian.Uint64(u[:8])
+	lo := binary.BigEndian.Uint64(u[8:])
+	for i := 25; i >= 0; i-- {
+		buf[i] = crockford[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(buf[:])
+}
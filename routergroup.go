@@ -39,6 +39,8 @@ type RouterGroup struct {
 	basePath string
 	engine   *Engine
 	root     bool
+	// requirements 是通过 RequireRoles 等方法加在组上的授权要求，注册路由时记录到路由上
+	requirements []string
 }
 
 var _ IRouter = &RouterGroup{} // 确保 RouterGroup 实现 IRouter 接口
@@ -53,9 +55,10 @@ func (group *RouterGroup) Use(middleware ...HandlerFunc) IRoutes {
 // 比如，用了同样中间件来实现授权的路由可以放在同一组
 func (group *RouterGroup) Group(relativePath string, handlers ...HandlerFunc) *RouterGroup {
 	return &RouterGroup{
-		Handlers: group.combineHandlers(handlers),
-		basePath: group.calculateAbsolutePath(relativePath),
-		engine:   group.engine,
+		Handlers:     group.combineHandlers(handlers),
+		basePath:     group.calculateAbsolutePath(relativePath),
+		engine:       group.engine,
+		requirements: group.requirements[:len(group.requirements):len(group.requirements)],
 	}
 }
 
@@ -67,6 +70,7 @@ func (group *RouterGroup) handle(httpMethod, relativePath string, handlers Handl
 	absolutePath := group.calculateAbsolutePath(relativePath)
 	handlers = group.combineHandlers(handlers)
 	group.engine.addRoute(httpMethod, absolutePath, handlers)
+	group.engine.setRoutePermissions(httpMethod, absolutePath, group.requirements)
 	return group.returnObj()
 }
 
@@ -0,0 +1,179 @@
+package jin
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// CSPNonceKey 是 Secure 中间件在 Context.Keys 中保存 CSP nonce 的键
+const CSPNonceKey = "jin/cspnonce"
+
+// cspNoncePlaceholder 会在 ContentSecurityPolicy 中被替换为每个请求的 nonce
+const cspNoncePlaceholder = "{nonce}"
+
+// SecureConfig 定义 Secure 中间件的配置
+type SecureConfig struct {
+	// AllowedHosts 是允许的 Host，为空时不检查
+	AllowedHosts []string
+
+	// SSLRedirect 为 true 时把 HTTP 请求重定向到 HTTPS
+	SSLRedirect bool
+
+	// SSLHost 是重定向的目标主机，为空时使用请求的 Host
+	SSLHost string
+
+	// SSLProxyHeaders 是反向代理设置的表示 HTTPS 的请求头，如 {"X-Forwarded-Proto": "https"}
+	SSLProxyHeaders map[string]string
+
+	// STSSeconds 是 Strict-Transport-Security 的 max-age，为 0 时不设置
+	STSSeconds int64
+
+	// STSIncludeSubdomains 为 true 时在 HSTS 中加入 includeSubDomains
+	STSIncludeSubdomains bool
+
+	// STSPreload 为 true 时在 HSTS 中加入 preload
+	STSPreload bool
+
+	// ForceSTSHeader 为 true 时在 HTTP 请求中也设置 HSTS
+	ForceSTSHeader bool
+
+	// FrameOptions 是 X-Frame-Options 的值，如 "DENY" 或 "SAMEORIGIN"
+	FrameOptions string
+
+	// ContentTypeNosniff 为 true 时设置 X-Content-Type-Options: nosniff
+	ContentTypeNosniff bool
+
+	// ContentSecurityPolicy 是 Content-Security-Policy 的值，
+	// 其中的 {nonce} 会被替换为每个请求随机生成的 nonce，可以通过 c.CSPNonce() 读取
+	ContentSecurityPolicy string
+
+	// ReferrerPolicy 是 Referrer-Policy 的值
+	ReferrerPolicy string
+
+	// PermissionsPolicy 是 Permissions-Policy 的值
+	PermissionsPolicy string
+
+	// BadHostHandler 在 Host 不被允许时调用
+	// 可选。默认返回 403
+	BadHostHandler HandlerFunc
+}
+
+// DefaultSecureConfig 返回一组适合大多数服务的安全响应头配置
+func DefaultSecureConfig() SecureConfig {
+	return SecureConfig{
+		SSLProxyHeaders:       map[string]string{"X-Forwarded-Proto": "https"},
+		STSSeconds:            31536000,
+		STSIncludeSubdomains:  true,
+		FrameOptions:          "DENY",
+		ContentTypeNosniff:    true,
+		ContentSecurityPolicy: "default-src 'self'",
+		ReferrerPolicy:        "strict-origin-when-cross-origin",
+	}
+}
+
+// Secure 返回一个设置安全响应头的中间件，可选地检查 Host 并把 HTTP 重定向到 HTTPS
+func Secure(conf SecureConfig) HandlerFunc {
+	if conf.BadHostHandler == nil {
+		conf.BadHostHandler = func(c *Context) {
+			c.AbortWithStatus(http.StatusForbidden)
+		}
+	}
+	allowedHosts := make(map[string]struct{}, len(conf.AllowedHosts))
+	for _, host := range conf.AllowedHosts {
+		allowedHosts[strings.ToLower(host)] = struct{}{}
+	}
+
+	sts := ""
+	if conf.STSSeconds > 0 {
+		sts = "max-age=" + strconv.FormatInt(conf.STSSeconds, 10)
+		if conf.STSIncludeSubdomains {
+			sts += "; includeSubDomains"
+		}
+		if conf.STSPreload {
+			sts += "; preload"
+		}
+	}
+	useNonce := strings.Contains(conf.ContentSecurityPolicy, cspNoncePlaceholder)
+
+	return func(c *Context) {
+		if len(allowedHosts) > 0 {
+			if _, ok := allowedHosts[strings.ToLower(c.Request.Host)]; !ok {
+				c.Abort()
+				conf.BadHostHandler(c)
+				return
+			}
+		}
+
+		isSSL := conf.isSSL(c.Request)
+		if conf.SSLRedirect && !isSSL {
+			url := *c.Request.URL
+			url.Scheme = "https"
+			url.Host = c.Request.Host
+			if conf.SSLHost != "" {
+				url.Host = conf.SSLHost
+			}
+			code := http.StatusMovedPermanently
+			if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+				code = http.StatusPermanentRedirect
+			}
+			http.Redirect(c.Writer, c.Request, url.String(), code)
+			c.Abort()
+			return
+		}
+
+		header := c.Writer.Header()
+		if sts != "" && (isSSL || conf.ForceSTSHeader) {
+			header.Set("Strict-Transport-Security", sts)
+		}
+		if conf.FrameOptions != "" {
+			header.Set("X-Frame-Options", conf.FrameOptions)
+		}
+		if conf.ContentTypeNosniff {
+			header.Set("X-Content-Type-Options", "nosniff")
+		}
+		if conf.ReferrerPolicy != "" {
+			header.Set("Referrer-Policy", conf.ReferrerPolicy)
+		}
+		if conf.PermissionsPolicy != "" {
+			header.Set("Permissions-Policy", conf.PermissionsPolicy)
+		}
+		if conf.ContentSecurityPolicy != "" {
+			csp := conf.ContentSecurityPolicy
+			if useNonce {
+				nonce := newCSPNonce()
+				c.Set(CSPNonceKey, nonce)
+				csp = strings.Replace(csp, cspNoncePlaceholder, nonce, -1)
+			}
+			header.Set("Content-Security-Policy", csp)
+		}
+	}
+}
+
+// isSSL 判断请求是否来自 HTTPS，支持反向代理设置的请求头
+func (conf *SecureConfig) isSSL(req *http.Request) bool {
+	if req.TLS != nil || strings.EqualFold(req.URL.Scheme, "https") {
+		return true
+	}
+	for k, v := range conf.SSLProxyHeaders {
+		if strings.EqualFold(req.Header.Get(k), v) {
+			return true
+		}
+	}
+	return false
+}
+
+// CSPNonce 返回 Secure 中间件为当前请求生成的 CSP nonce，用于 <script nonce="...">
+func (c *Context) CSPNonce() string {
+	return c.GetString(CSPNonceKey)
+}
+
+func newCSPNonce() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.StdEncoding.EncodeToString(b)
+}
@@ -0,0 +1,139 @@
+package sessions
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"io"
+	"time"
+)
+
+var (
+	errNoKeys          = errors.New("sessions: at least one hash key is required")
+	errInvalidValue    = errors.New("sessions: the cookie value is not valid")
+	errExpired         = errors.New("sessions: the cookie value has expired")
+	errValueTooLong    = errors.New("sessions: the encoded value is too long")
+	errInvalidBlockKey = errors.New("sessions: block key must be 16, 24 or 32 bytes")
+)
+
+// maxCookieLength 是浏览器普遍支持的单个 cookie 最大长度
+const maxCookieLength = 4096
+
+// codec 使用 HMAC-SHA256 签名，提供 blockKey 时再用 AES-GCM 加密
+type codec struct {
+	hashKey []byte
+	aead    cipher.AEAD
+}
+
+// codecs 按顺序尝试解码，只用第一个编码，以此实现密钥轮换
+type codecs []codec
+
+// newCodecs 根据 hashKey/blockKey 对创建编解码器，blockKey 可以为 nil 表示只签名不加密
+// 新的密钥放在最前面，旧的密钥放在后面，旧密钥签发的 cookie 仍能被读取
+func newCodecs(keyPairs ...[]byte) (codecs, error) {
+	if len(keyPairs) == 0 {
+		return nil, errNoKeys
+	}
+	cs := make(codecs, 0, (len(keyPairs)+1)/2)
+	for i := 0; i < len(keyPairs); i += 2 {
+		c := codec{hashKey: keyPairs[i]}
+		if len(c.hashKey) == 0 {
+			return nil, errNoKeys
+		}
+		if i+1 < len(keyPairs) && keyPairs[i+1] != nil {
+			block, err := aes.NewCipher(keyPairs[i+1])
+			if err != nil {
+				return nil, errInvalidBlockKey
+			}
+			if c.aead, err = cipher.NewGCM(block); err != nil {
+				return nil, err
+			}
+		}
+		cs = append(cs, c)
+	}
+	return cs, nil
+}
+
+func (cs codecs) encode(name string, value interface{}) (string, error) {
+	return cs[0].encode(name, value)
+}
+
+// decode 依次尝试所有密钥，maxAge>0 时拒绝超过 maxAge 秒的值
+func (cs codecs) decode(name, value string, maxAge int, dst interface{}) error {
+	err := errInvalidValue
+	for _, c := range cs {
+		if err = c.decode(name, value, maxAge, dst); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+func (c codec) encode(name string, value interface{}) (string, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(value); err != nil {
+		return "", err
+	}
+	payload := buf.Bytes()
+	if c.aead != nil {
+		nonce := make([]byte, c.aead.NonceSize())
+		if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+			return "", err
+		}
+		payload = c.aead.Seal(nonce, nonce, payload, []byte(name))
+	}
+
+	b := make([]byte, 8, 8+len(payload)+sha256.Size)
+	binary.BigEndian.PutUint64(b, uint64(time.Now().Unix()))
+	b = append(b, payload...)
+	b = append(b, c.mac(name, b)...)
+
+	encoded := base64.RawURLEncoding.EncodeToString(b)
+	if len(name)+len(encoded) > maxCookieLength {
+		return "", errValueTooLong
+	}
+	return encoded, nil
+}
+
+func (c codec) decode(name, value string, maxAge int, dst interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || len(b) < 8+sha256.Size {
+		return errInvalidValue
+	}
+	data, mac := b[:len(b)-sha256.Size], b[len(b)-sha256.Size:]
+	if !hmac.Equal(mac, c.mac(name, data)) {
+		return errInvalidValue
+	}
+
+	ts := int64(binary.BigEndian.Uint64(data[:8]))
+	if maxAge > 0 && ts+int64(maxAge) < time.Now().Unix() {
+		return errExpired
+	}
+
+	payload := data[8:]
+	if c.aead != nil {
+		nonceSize := c.aead.NonceSize()
+		if len(payload) < nonceSize {
+			return errInvalidValue
+		}
+		if payload, err = c.aead.Open(nil, payload[:nonceSize], payload[nonceSize:], []byte(name)); err != nil {
+			return errInvalidValue
+		}
+	}
+	return gob.NewDecoder(bytes.NewReader(payload)).Decode(dst)
+}
+
+func (c codec) mac(name string, data []byte) []byte {
+	h := hmac.New(sha256.New, c.hashKey)
+	h.Write([]byte(name))
+	h.Write([]byte{'|'})
+	h.Write(data)
+	return h.Sum(nil)
+}
@@ -0,0 +1,63 @@
+package sessions
+
+import (
+	"encoding/gob"
+	"jin"
+)
+
+func init() {
+	// flash 消息以 []interface{} 保存在会话中
+	gob.Register([]interface{}{})
+	gob.Register(map[string]interface{}{})
+}
+
+// CookieStore 把会话数据签名并加密后保存在 cookie 中
+type CookieStore struct {
+	codecs  codecs
+	options Options
+}
+
+var _ Store = &CookieStore{}
+
+// NewCookieStore 返回一个 cookie 存储，keyPairs 是 hashKey/blockKey 对
+// hashKey 用于 HMAC 签名，blockKey 用于 AES 加密，长度必须是 16、24 或 32 字节，为 nil 时只签名。
+// 轮换密钥时把新的密钥对放在最前面，旧的密钥对仍可以解码已经签发的 cookie
+func NewCookieStore(keyPairs ...[]byte) (*CookieStore, error) {
+	cs, err := newCodecs(keyPairs...)
+	if err != nil {
+		return nil, err
+	}
+	return &CookieStore{codecs: cs, options: DefaultOptions()}, nil
+}
+
+// Options 设置新会话的 cookie 属性
+func (s *CookieStore) Options(options Options) {
+	s.options = options
+}
+
+func (s *CookieStore) Load(c *jin.Context, name string) (*Data, error) {
+	data := &Data{Options: s.options, IsNew: true}
+	value, err := c.Cookie(name)
+	if err != nil {
+		return data, nil
+	}
+	if err := s.codecs.decode(name, value, s.options.MaxAge, &data.Values); err != nil {
+		data.Values = nil
+		return data, err
+	}
+	data.IsNew = false
+	return data, nil
+}
+
+func (s *CookieStore) Save(c *jin.Context, name string, data *Data) error {
+	if data.Options.MaxAge < 0 {
+		c.SetCookieData(data.Options.cookie(name, ""))
+		return nil
+	}
+	encoded, err := s.codecs.encode(name, data.Values)
+	if err != nil {
+		return err
+	}
+	c.SetCookieData(data.Options.cookie(name, encoded))
+	return nil
+}
@@ -0,0 +1,134 @@
+package sessions
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"jin"
+	"sync"
+	"time"
+)
+
+// MemoryStore 把会话数据保存在进程内存中，cookie 里只有签名后的会话 ID
+type MemoryStore struct {
+	codecs  codecs
+	options Options
+
+	mu       sync.Mutex
+	sessions map[string]memorySession
+	lastGC   time.Time
+}
+
+type memorySession struct {
+	values  map[string]interface{}
+	expires time.Time
+}
+
+var _ Store = &MemoryStore{}
+
+// NewMemoryStore 返回一个内存存储，keyPairs 用于给 cookie 中的会话 ID 签名，参见 NewCookieStore
+// 会话在 Options.MaxAge 秒后过期，MaxAge 为 0 时使用 24 小时
+func NewMemoryStore(keyPairs ...[]byte) (*MemoryStore, error) {
+	cs, err := newCodecs(keyPairs...)
+	if err != nil {
+		return nil, err
+	}
+	return &MemoryStore{
+		codecs:   cs,
+		options:  DefaultOptions(),
+		sessions: make(map[string]memorySession),
+	}, nil
+}
+
+// Options 设置新会话的 cookie 属性
+func (s *MemoryStore) Options(options Options) {
+	s.options = options
+}
+
+func (s *MemoryStore) Load(c *jin.Context, name string) (*Data, error) {
+	data := &Data{Options: s.options, IsNew: true}
+	value, err := c.Cookie(name)
+	if err != nil {
+		return data, nil
+	}
+	var id string
+	if err := s.codecs.decode(name, value, s.options.MaxAge, &id); err != nil {
+		return data, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess, ok := s.sessions[id]
+	if !ok || time.Now().After(sess.expires) {
+		delete(s.sessions, id)
+		return data, nil
+	}
+	data.ID = id
+	data.IsNew = false
+	data.Values = make(map[string]interface{}, len(sess.values))
+	for k, v := range sess.values {
+		data.Values[k] = v
+	}
+	return data, nil
+}
+
+func (s *MemoryStore) Save(c *jin.Context, name string, data *Data) error {
+	if data.Options.MaxAge < 0 {
+		if data.ID != "" {
+			s.mu.Lock()
+			delete(s.sessions, data.ID)
+			s.mu.Unlock()
+		}
+		c.SetCookieData(data.Options.cookie(name, ""))
+		return nil
+	}
+	if data.ID == "" {
+		id, err := newSessionID()
+		if err != nil {
+			return err
+		}
+		data.ID = id
+	}
+	encoded, err := s.codecs.encode(name, data.ID)
+	if err != nil {
+		return err
+	}
+
+	ttl := time.Duration(data.Options.MaxAge) * time.Second
+	if ttl == 0 {
+		ttl = 24 * time.Hour
+	}
+	values := make(map[string]interface{}, len(data.Values))
+	for k, v := range data.Values {
+		values[k] = v
+	}
+
+	s.mu.Lock()
+	now := time.Now()
+	s.sessions[data.ID] = memorySession{values: values, expires: now.Add(ttl)}
+	s.gc(now)
+	s.mu.Unlock()
+
+	c.SetCookieData(data.Options.cookie(name, encoded))
+	return nil
+}
+
+// gc 清除过期的会话，最多每分钟执行一次，调用者需要持有锁
+func (s *MemoryStore) gc(now time.Time) {
+	if now.Sub(s.lastGC) < time.Minute {
+		return
+	}
+	s.lastGC = now
+	for id, sess := range s.sessions {
+		if now.After(sess.expires) {
+			delete(s.sessions, id)
+		}
+	}
+}
+
+func newSessionID() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
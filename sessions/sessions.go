@@ -0,0 +1,187 @@
+package sessions
+
+import (
+	"jin"
+	"net/http"
+)
+
+// DefaultKey 是 Sessions 中间件在 Context.Keys 中保存会话的键
+const DefaultKey = "jin/sessions"
+
+const flashesKey = "_flash"
+
+// Options 是会话 cookie 的属性
+type Options struct {
+	Path   string
+	Domain string
+	// MaxAge=0 表示浏览器关闭时失效，MaxAge<0 表示立即删除，MaxAge>0 表示存活的秒数
+	MaxAge   int
+	Secure   bool
+	HttpOnly bool
+	SameSite http.SameSite
+}
+
+// DefaultOptions 返回默认的 cookie 属性：30 天、HttpOnly、SameSite=Lax
+func DefaultOptions() Options {
+	return Options{
+		Path:     "/",
+		MaxAge:   86400 * 30,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+func (o Options) cookie(name, value string) *http.Cookie {
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     o.Path,
+		Domain:   o.Domain,
+		MaxAge:   o.MaxAge,
+		Secure:   o.Secure,
+		HttpOnly: o.HttpOnly,
+		SameSite: o.SameSite,
+	}
+}
+
+// Data 是存储读写的会话数据
+type Data struct {
+	// ID 是服务端存储使用的会话 ID，cookie 存储不使用
+	ID      string
+	Values  map[string]interface{}
+	Options Options
+	IsNew   bool
+}
+
+// Store 读取和保存会话
+type Store interface {
+	// Load 读取请求中名为 name 的会话，不存在或校验失败时返回一个新会话
+	Load(c *jin.Context, name string) (*Data, error)
+	// Save 保存会话并写入 cookie，data.Options.MaxAge < 0 时删除会话
+	Save(c *jin.Context, name string, data *Data) error
+}
+
+// Session 是处理器使用的会话
+type Session interface {
+	// ID 返回服务端存储的会话 ID
+	ID() string
+	// Get 返回 key 对应的值
+	Get(key string) interface{}
+	// Set 设置 key 对应的值
+	Set(key string, val interface{})
+	// Delete 删除 key 对应的值
+	Delete(key string)
+	// Clear 删除所有值
+	Clear()
+	// AddFlash 添加一条只能被读取一次的消息，vars 可以指定消息的类别
+	AddFlash(value interface{}, vars ...string)
+	// Flashes 返回并删除消息
+	Flashes(vars ...string) []interface{}
+	// Options 设置会话 cookie 的属性，MaxAge<0 时 Save 会删除会话
+	Options(Options)
+	// Save 保存会话，必须在写入响应体之前调用
+	Save() error
+}
+
+// Sessions 返回一个会话中间件，会话数据在第一次访问时才从 store 读取
+func Sessions(name string, store Store) jin.HandlerFunc {
+	return func(c *jin.Context) {
+		c.Set(DefaultKey, &session{name: name, store: store, c: c})
+	}
+}
+
+// Default 返回 Sessions 中间件创建的会话
+func Default(c *jin.Context) Session {
+	return c.MustGet(DefaultKey).(Session)
+}
+
+type session struct {
+	name    string
+	store   Store
+	c       *jin.Context
+	data    *Data
+	written bool
+}
+
+var _ Session = &session{}
+
+func (s *session) load() *Data {
+	if s.data == nil {
+		data, err := s.store.Load(s.c, s.name)
+		if err != nil {
+			s.c.Error(err)
+		}
+		if data.Values == nil {
+			data.Values = make(map[string]interface{})
+		}
+		s.data = data
+	}
+	return s.data
+}
+
+func (s *session) ID() string {
+	return s.load().ID
+}
+
+func (s *session) Get(key string) interface{} {
+	return s.load().Values[key]
+}
+
+func (s *session) Set(key string, val interface{}) {
+	s.load().Values[key] = val
+	s.written = true
+}
+
+func (s *session) Delete(key string) {
+	delete(s.load().Values, key)
+	s.written = true
+}
+
+func (s *session) Clear() {
+	data := s.load()
+	for key := range data.Values {
+		delete(data.Values, key)
+	}
+	s.written = true
+}
+
+func (s *session) AddFlash(value interface{}, vars ...string) {
+	key := flashKey(vars)
+	flashes, _ := s.load().Values[key].([]interface{})
+	s.data.Values[key] = append(flashes, value)
+	s.written = true
+}
+
+func (s *session) Flashes(vars ...string) []interface{} {
+	key := flashKey(vars)
+	flashes, _ := s.load().Values[key].([]interface{})
+	if len(flashes) > 0 {
+		delete(s.data.Values, key)
+		s.written = true
+	}
+	return flashes
+}
+
+func (s *session) Options(options Options) {
+	s.load().Options = options
+	s.written = true
+}
+
+// Save 只在会话被修改过时才写入 store
+func (s *session) Save() error {
+	if !s.written {
+		return nil
+	}
+	if err := s.store.Save(s.c, s.name, s.load()); err != nil {
+		return err
+	}
+	s.written = false
+	return nil
+}
+
+func flashKey(vars []string) string {
+	if len(vars) > 0 {
+		return flashesKey + "_" + vars[0]
+	}
+	return flashesKey
+}
@@ -0,0 +1,219 @@
+package jin
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// TimeoutConfig 定义 Timeout 中间件的配置
+type TimeoutConfig struct {
+	// Timeout 是处理器链的最长执行时间
+	Timeout time.Duration
+
+	// StatusCode 是超时时返回的状态码，通常为 503 或 504
+	// 可选。默认值为 503
+	StatusCode int
+
+	// Response 在超时时被调用，用来写入自定义响应
+	// 可选。默认返回 StatusCode 和它的状态文本
+	Response HandlerFunc
+}
+
+// Timeout 返回一个给请求设置截止时间的中间件，超时返回 503
+func Timeout(timeout time.Duration) HandlerFunc {
+	return TimeoutWithConfig(TimeoutConfig{Timeout: timeout})
+}
+
+// TimeoutWithConfig 返回一个给请求设置截止时间的中间件
+// 后续的处理器在另一个 goroutine 中运行在 Context 的副本上，响应先写入缓冲区，
+// 按时完成才会写给客户端；超时后处理器的写入会被丢弃并返回 http.ErrHandlerTimeout。
+// 由于副本不属于 Context 池，超时的处理器继续运行时原 Context 可以被安全地复用
+func TimeoutWithConfig(conf TimeoutConfig) HandlerFunc {
+	assert1(conf.Timeout > 0, "timeout must be greater than 0")
+	if conf.StatusCode == 0 {
+		conf.StatusCode = http.StatusServiceUnavailable
+	}
+	if conf.Response == nil {
+		conf.Response = func(c *Context) {
+			c.String(conf.StatusCode, http.StatusText(conf.StatusCode))
+		}
+	}
+
+	return func(c *Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), conf.Timeout)
+		defer cancel()
+
+		tw := newTimeoutWriter(c.Writer)
+		cp := c.timeoutCopy(tw, c.Request.WithContext(ctx))
+
+		done := make(chan struct{})
+		panicChan := make(chan interface{}, 1)
+		go func() {
+			defer func() {
+				if p := recover(); p != nil {
+					panicChan <- p
+					return
+				}
+				close(done)
+			}()
+			cp.Next()
+		}()
+
+		select {
+		case p := <-panicChan:
+			tw.discard()
+			panic(p)
+		case <-done:
+			c.index = cp.index
+			c.Keys = cp.Keys
+			c.Errors = append(c.Errors, cp.Errors...)
+			tw.flushTo(c.Writer)
+		case <-ctx.Done():
+			tw.discard()
+			c.Abort()
+			conf.Response(c)
+		}
+	}
+}
+
+// timeoutCopy 返回一个用于在 goroutine 中继续执行处理器链的副本，
+// 它不共享 Keys、Params 和 Errors 的底层存储，因此不会与池中复用的 Context 产生数据竞争
+func (c *Context) timeoutCopy(w ResponseWriter, req *http.Request) *Context {
+	var cp = *c
+	cp.writermem.ResponseWriter = nil
+	cp.Writer = w
+	cp.Request = req
+	cp.Errors = nil
+	cp.Keys = make(map[string]interface{}, len(c.Keys))
+	for k, v := range c.Keys {
+		cp.Keys[k] = v
+	}
+	cp.Params = make(Params, len(c.Params))
+	copy(cp.Params, c.Params)
+	return &cp
+}
+
+// timeoutWriter 缓存处理器写入的响应头和响应体，超时后丢弃所有写入
+type timeoutWriter struct {
+	mu          sync.Mutex
+	header      http.Header
+	body        bytes.Buffer
+	status      int
+	wroteHeader bool
+	timedOut    bool
+}
+
+var _ ResponseWriter = &timeoutWriter{}
+
+var errTimeoutHijack = errors.New("jin: Hijack is not supported in Timeout middleware")
+
+func newTimeoutWriter(w ResponseWriter) *timeoutWriter {
+	return &timeoutWriter{
+		header: w.Header().Clone(),
+		status: w.Status(),
+	}
+}
+
+func (w *timeoutWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *timeoutWriter) WriteHeader(code int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if code > 0 && !w.timedOut && !w.wroteHeader {
+		w.status = code
+	}
+}
+
+func (w *timeoutWriter) WriteHeaderNow() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.wroteHeader = true
+}
+
+func (w *timeoutWriter) Write(data []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	w.wroteHeader = true
+	return w.body.Write(data)
+}
+
+func (w *timeoutWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *timeoutWriter) Status() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.status
+}
+
+func (w *timeoutWriter) Size() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.wroteHeader {
+		return noWritten
+	}
+	return w.body.Len()
+}
+
+func (w *timeoutWriter) Written() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.wroteHeader
+}
+
+// Flush 在缓冲模式下没有意义，响应会在处理器完成后一次写出
+func (w *timeoutWriter) Flush() {}
+
+func (w *timeoutWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return nil, nil, errTimeoutHijack
+}
+
+// CloseNotify 返回 nil，处理器应该通过 c.Request.Context() 感知超时和断开
+func (w *timeoutWriter) CloseNotify() <-chan bool {
+	return nil
+}
+
+func (w *timeoutWriter) Pusher() http.Pusher {
+	return nil
+}
+
+// discard 标记超时并丢弃已缓存的内容，之后的写入都会失败
+func (w *timeoutWriter) discard() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.timedOut = true
+	w.body.Reset()
+}
+
+// flushTo 把缓存的响应写到真正的 ResponseWriter
+func (w *timeoutWriter) flushTo(dst ResponseWriter) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	header := dst.Header()
+	for k := range header {
+		if _, ok := w.header[k]; !ok {
+			header.Del(k)
+		}
+	}
+	for k, v := range w.header {
+		header[k] = v
+	}
+	dst.WriteHeader(w.status)
+	if w.body.Len() > 0 {
+		dst.Write(w.body.Bytes())
+	} else if w.wroteHeader {
+		dst.WriteHeaderNow()
+	}
+}
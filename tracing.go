@@ -0,0 +1,326 @@
+package jin
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"jin/internal/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// W3C Trace Context 使用的请求头
+const (
+	HeaderTraceparent = "traceparent"
+	HeaderTracestate  = "tracestate"
+)
+
+// maxTracestateLength 是 W3C 规范要求至少支持的 tracestate 长度，超过时丢弃
+const maxTracestateLength = 512
+
+// TraceID 是 16 字节的追踪 ID
+type TraceID [16]byte
+
+func (id TraceID) String() string { return hex.EncodeToString(id[:]) }
+
+// SpanID 是 8 字节的 span ID
+type SpanID [8]byte
+
+func (id SpanID) String() string { return hex.EncodeToString(id[:]) }
+
+// SpanContext 是跨进程传播的追踪信息
+type SpanContext struct {
+	TraceID    TraceID
+	SpanID     SpanID
+	Sampled    bool
+	TraceState string
+}
+
+// IsValid 判断 TraceID 和 SpanID 是否都不为全零
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != TraceID{} && sc.SpanID != SpanID{}
+}
+
+// Traceparent 返回 version 00 格式的 traceparent 值
+func (sc SpanContext) Traceparent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return "00-" + sc.TraceID.String() + "-" + sc.SpanID.String() + "-" + flags
+}
+
+// ParseTraceparent 解析 traceparent 请求头，格式为 version-traceid-parentid-flags
+// 未知版本只要前四个字段合法也会被接受，版本 ff 和全零的 ID 是非法的
+func ParseTraceparent(value string) (SpanContext, bool) {
+	var sc SpanContext
+	value = strings.TrimSpace(value)
+	if len(value) < 55 || value[2] != '-' || value[35] != '-' || value[52] != '-' {
+		return sc, false
+	}
+	version, err := hex.DecodeString(value[0:2])
+	if err != nil || version[0] == 0xff || (version[0] == 0 && len(value) != 55) {
+		return sc, false
+	}
+	if len(value) > 55 && value[55] != '-' {
+		return sc, false
+	}
+	if !isLowerHex(value[3:35]) || !isLowerHex(value[36:52]) || !isLowerHex(value[53:55]) {
+		return sc, false
+	}
+	hex.Decode(sc.TraceID[:], []byte(value[3:35]))
+	hex.Decode(sc.SpanID[:], []byte(value[36:52]))
+	flags, _ := hex.DecodeString(value[53:55])
+	sc.Sampled = flags[0]&0x01 == 1
+	return sc, sc.IsValid()
+}
+
+func isLowerHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if !('0' <= s[i] && s[i] <= '9' || 'a' <= s[i] && s[i] <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// Span 是一次操作的追踪记录，nil *Span 的所有方法都是空操作
+type Span struct {
+	Name       string                 `json:"name"`
+	TraceID    string                 `json:"trace_id"`
+	SpanID     string                 `json:"span_id"`
+	ParentID   string                 `json:"parent_id,omitempty"`
+	Start      time.Time              `json:"start"`
+	End        time.Time              `json:"end"`
+	Duration   time.Duration          `json:"duration_ns"`
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+	Errors     []string               `json:"errors,omitempty"`
+
+	sc       SpanContext
+	exporter SpanExporter
+	mu       sync.Mutex
+	ended    bool
+}
+
+// SpanContext 返回用于传播的追踪信息
+func (s *Span) SpanContext() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.sc
+}
+
+// SetAttribute 设置 span 的属性
+func (s *Span) SetAttribute(key string, value interface{}) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.Attributes == nil {
+		s.Attributes = make(map[string]interface{})
+	}
+	s.Attributes[key] = value
+	s.mu.Unlock()
+}
+
+// RecordError 记录一个错误
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	s.Errors = append(s.Errors, err.Error())
+	s.mu.Unlock()
+}
+
+// StartChild 创建一个子 span
+func (s *Span) StartChild(name string) *Span {
+	if s == nil {
+		return nil
+	}
+	child := newSpan(name, s.sc.TraceID, s.exporter)
+	child.ParentID = s.SpanID
+	child.sc.Sampled = s.sc.Sampled
+	child.sc.TraceState = s.sc.TraceState
+	return child
+}
+
+// Finish 结束 span，采样的 span 会交给导出器，重复调用无效
+func (s *Span) Finish() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.End = time.Now()
+	s.Duration = s.End.Sub(s.Start)
+	s.mu.Unlock()
+	if s.sc.Sampled && s.exporter != nil {
+		s.exporter.ExportSpan(s)
+	}
+}
+
+func newSpan(name string, traceID TraceID, exporter SpanExporter) *Span {
+	s := &Span{Name: name, Start: time.Now(), exporter: exporter}
+	s.sc.TraceID = traceID
+	if _, err := rand.Read(s.sc.SpanID[:]); err != nil {
+		panic(err)
+	}
+	s.TraceID = traceID.String()
+	s.SpanID = s.sc.SpanID.String()
+	return s
+}
+
+// SpanExporter 接收结束的 span，实现需要是并发安全的
+type SpanExporter interface {
+	ExportSpan(span *Span)
+}
+
+// JSONExporter 把每个 span 以一行 JSON 写入 Writer，适合本地调试
+type JSONExporter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+var _ SpanExporter = &JSONExporter{}
+
+// NewJSONExporter 创建一个写入 w 的导出器，w 为 nil 时写入 DefaultWriter
+func NewJSONExporter(w io.Writer) *JSONExporter {
+	if w == nil {
+		w = DefaultWriter
+	}
+	return &JSONExporter{w: w}
+}
+
+func (e *JSONExporter) ExportSpan(span *Span) {
+	span.mu.Lock()
+	data, err := json.Marshal(span)
+	span.mu.Unlock()
+	if err != nil {
+		return
+	}
+	e.mu.Lock()
+	e.w.Write(append(data, '\n'))
+	e.mu.Unlock()
+}
+
+type spanContextKey struct{}
+
+// ContextWithSpan 返回一个携带 span 的 context
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	return context.WithValue(ctx, spanContextKey{}, span)
+}
+
+// SpanFromContext 返回 context 中的 span，没有时返回 nil
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanContextKey{}).(*Span)
+	return span
+}
+
+// TracingConfig 定义 Tracing 中间件的配置
+type TracingConfig struct {
+	// Exporter 接收结束的 span
+	Exporter SpanExporter
+
+	// Sampled 决定没有 traceparent 的请求是否被采样，带有 traceparent 的请求沿用上游的决定
+	// 可选。默认值为 nil，表示全部采样
+	Sampled func(c *Context) bool
+}
+
+// Tracing 返回一个使用 exporter 的追踪中间件
+func Tracing(exporter SpanExporter) HandlerFunc {
+	return TracingWithConfig(TracingConfig{Exporter: exporter})
+}
+
+// TracingWithConfig 返回一个追踪中间件，它为每个请求创建一个名为 "方法 路由" 的 span，
+// 请求带有合法的 traceparent 时沿用其中的 trace ID 作为父 span。
+// span 保存在请求的 context 中，可以通过 SpanFromContext(c.Request.Context()) 或 c.Span() 读取，
+// 绑定和渲染会各自产生子 span。请求结束时记录状态码和 c.Errors
+func TracingWithConfig(conf TracingConfig) HandlerFunc {
+	assert1(conf.Exporter != nil, "tracing exporter can not be nil")
+	return func(c *Context) {
+		name := c.Request.Method
+		if fullPath := c.FullPath(); fullPath != "" {
+			name += " " + fullPath
+		}
+
+		var span *Span
+		if parent, ok := ParseTraceparent(c.requestHeader(HeaderTraceparent)); ok {
+			span = newSpan(name, parent.TraceID, conf.Exporter)
+			span.ParentID = parent.SpanID.String()
+			span.sc.Sampled = parent.Sampled
+			if ts := c.requestHeader(HeaderTracestate); len(ts) <= maxTracestateLength {
+				span.sc.TraceState = ts
+			}
+		} else {
+			var traceID TraceID
+			if _, err := rand.Read(traceID[:]); err != nil {
+				panic(err)
+			}
+			span = newSpan(name, traceID, conf.Exporter)
+			span.sc.Sampled = conf.Sampled == nil || conf.Sampled(c)
+		}
+		span.SetAttribute("http.method", c.Request.Method)
+		span.SetAttribute("http.route", c.FullPath())
+		span.SetAttribute("http.target", c.Request.URL.RequestURI())
+
+		c.Request = c.Request.WithContext(ContextWithSpan(c.Request.Context(), span))
+		defer span.Finish()
+
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttribute("http.status_code", status)
+		if status >= http.StatusInternalServerError {
+			span.SetAttribute("error", true)
+		}
+		for _, err := range c.Errors {
+			span.RecordError(err)
+		}
+	}
+}
+
+// Span 返回 Tracing 中间件为当前请求创建的 span，没有使用该中间件时返回 nil
+func (c *Context) Span() *Span {
+	if c.Request == nil {
+		return nil
+	}
+	return SpanFromContext(c.Request.Context())
+}
+
+// InjectTraceContext 把 ctx 中 span 的追踪信息写入 header，用于向下游传播
+func InjectTraceContext(ctx context.Context, header http.Header) {
+	sc := SpanFromContext(ctx).SpanContext()
+	if !sc.IsValid() {
+		return
+	}
+	header.Set(HeaderTraceparent, sc.Traceparent())
+	if sc.TraceState != "" {
+		header.Set(HeaderTracestate, sc.TraceState)
+	}
+}
+
+// TracingTransport 是一个 http.RoundTripper，它把请求 context 中的追踪信息写入 traceparent 和 tracestate
+type TracingTransport struct {
+	// Base 为 nil 时使用 http.DefaultTransport
+	Base http.RoundTripper
+}
+
+func (t *TracingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if SpanFromContext(req.Context()) != nil && req.Header.Get(HeaderTraceparent) == "" {
+		req = req.Clone(req.Context())
+		InjectTraceContext(req.Context(), req.Header)
+	}
+	return base.RoundTrip(req)
+}
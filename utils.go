@@ -30,6 +30,14 @@ func Bind(val interface{}) HandlerFunc {
 	}
 }
 
+// MaxBodyBytes 返回一个为当前路由设置请求体大小上限的中间件，覆盖 Engine.MaxBodyBytes
+// n 为负数时表示此路由不限制请求体大小
+func MaxBodyBytes(n int64) HandlerFunc {
+	return func(c *Context) {
+		c.maxBodyBytes = n
+	}
+}
+
 func WrapF(f http.HandlerFunc) HandlerFunc {
 	return func(c *Context) {
 		f(c.Writer, c.Request)
@@ -138,3 +146,26 @@ func resolveAddress(addr []string) string {
 		panic("too many parameters")
 	}
 }
+
+// varyValues 返回响应 Vary 头中列出的所有请求头名
+func varyValues(header http.Header) []string {
+	var values []string
+	for _, line := range header.Values("Vary") {
+		for _, v := range strings.Split(line, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				values = append(values, v)
+			}
+		}
+	}
+	return values
+}
+
+// addVary 在 Vary 中追加 value，已经存在时不重复添加，不会覆盖其他中间件设置的值
+func addVary(header http.Header, value string) {
+	for _, v := range varyValues(header) {
+		if strings.EqualFold(v, value) || v == "*" {
+			return
+		}
+	}
+	header.Add("Vary", value)
+}